@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/export"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// loadConfigOrDefault loads the configuration, falling back to the defaults
+func loadConfigOrDefault() *config.Config {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Error loading config, using defaults: %v\n", err)
+		cfg = config.DefaultConfig()
+	}
+	return cfg
+}
+
+// runExport converts an org file to another format:
+//
+//	org export [--format md] [-o output] [-m] [-r] [-a] [file.org]
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "md", "Output format: md or html")
+	output := flags.String("o", "", "Write to this file instead of standard output")
+	multiMode := flags.Bool("m", false, "Export all org files in the directory")
+	recursive := flags.Bool("r", false, "Export the org files in subdirectories too, implies -m")
+	agendaMode := flags.Bool("a", false, "Export all agenda_files from the config")
+	flags.Parse(args)
+
+	cfg := loadConfigOrDefault()
+	var orgFile *model.OrgFile
+	var err error
+	if *agendaMode {
+		orgFile, err = loadAgendaFiles(nil, cfg)
+	} else {
+		orgFile, err = loadOrgFile(flags.Arg(0), *multiMode || *recursive, *recursive, cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+
+	var write func(io.Writer) error
+	switch *format {
+	case "md", "markdown":
+		write = func(w io.Writer) error { return export.Markdown(w, orgFile) }
+	case "html":
+		write = func(w io.Writer) error { return export.HTML(w, orgFile, cfg, time.Now()) }
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown export format %q\n", *format)
+		os.Exit(1)
+	}
+
+	if err := writeOutput(*output, false, write); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runImport converts a file in another format to org text, appending it to an
+// org file or writing it to standard output:
+//
+//	org import [--format md] [-o todo.org] [file.md]
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "md", "Input format: md")
+	output := flags.String("o", "", "Append to this org file instead of writing to standard output")
+	flags.Parse(args)
+
+	input := io.Reader(os.Stdin)
+	if path := flags.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	cfg := loadConfigOrDefault()
+	var write func(io.Writer) error
+	switch *format {
+	case "md", "markdown":
+		write = func(w io.Writer) error { return export.ImportMarkdown(input, w, cfg) }
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown import format %q\n", *format)
+		os.Exit(1)
+	}
+
+	if err := writeOutput(*output, true, write); err != nil {
+		fmt.Fprintf(os.Stderr, "Error importing: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// writeOutput runs write on the named file, or on standard output if the name is
+// empty. In append mode the text is added at the end of the file on a new line.
+func writeOutput(path string, appendMode bool, write func(io.Writer) error) error {
+	if path == "" {
+		return write(os.Stdout)
+	}
+	if !appendMode {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := write(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -15,11 +15,32 @@ import (
 )
 
 func main() {
+	// Subcommands are handled before the flags of the interactive mode
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "notify":
+			runNotify(os.Args[2:])
+			return
+		}
+	}
+
 	var filePath string
 	var multiMode bool
+	var recursive bool
+	var agendaMode bool
 	var captureMode bool
 	flag.BoolVar(&multiMode, "multi", false, "Load all org files in current directory as top-level items")
 	flag.BoolVar(&multiMode, "m", false, "Load all org files in current directory (shorthand)")
+	flag.BoolVar(&recursive, "recursive", false, "Load org files in subdirectories too, implies -multi")
+	flag.BoolVar(&recursive, "r", false, "Load org files in subdirectories too (shorthand)")
+	flag.BoolVar(&agendaMode, "agenda", false, "Open the agenda over all agenda_files from the config")
+	flag.BoolVar(&agendaMode, "a", false, "Open the agenda over all agenda_files (shorthand)")
 	flag.BoolVar(&captureMode, "capture", false, "Start in capture mode")
 	flag.BoolVar(&captureMode, "c", false, "Start in capture mode (shorthand)")
 	flag.Parse()
@@ -57,14 +78,83 @@ func main() {
 	}
 
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg := loadConfigOrDefault()
+
+	// Either all agenda files are opened together, or one file (or directory) is
+	// edited while the other agenda files only show up in the agenda view
+	var orgFile, agendaFiles *model.OrgFile
+	var err error
+	if agendaMode {
+		orgFile, err = loadAgendaFiles(nil, cfg)
+	} else {
+		orgFile, err = loadOrgFile(filePath, multiMode || recursive, recursive, cfg)
+		if err == nil {
+			agendaFiles, err = loadAgendaFiles(orgFile, cfg)
+		}
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: Error loading config, using defaults: %v\n", err)
-		cfg = config.DefaultConfig()
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+
+	// Run the UI
+	if err := ui.RunUI(orgFile, agendaFiles, cfg, agendaMode, captureMode, captureText); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Save on exit; only the files that changed are written
+	if err := parser.Save(orgFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving file: %v\n", err)
+		os.Exit(1)
+	}
+	if agendaFiles != nil {
+		if err := parser.Save(agendaFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving agenda files: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// loadAgendaFiles loads the agenda_files from the config, leaving out the files
+// already loaded in editing. Without a file being edited the agenda files are
+// required; otherwise nil is returned if there are no other agenda files.
+func loadAgendaFiles(editing *model.OrgFile, cfg *config.Config) (*model.OrgFile, error) {
+	if len(cfg.Files.AgendaFiles) == 0 {
+		if editing == nil {
+			return nil, fmt.Errorf("loading agenda files: set agenda_files in the [files] config")
+		}
+		return nil, nil
+	}
+
+	var loaded []string
+	if editing != nil {
+		if editing.IsMultiFile() {
+			for _, fileItem := range model.FileItems(editing.Items) {
+				loaded = append(loaded, fileItem.SourceFile)
+			}
+		} else {
+			loaded = append(loaded, editing.Path)
+		}
 	}
 
-	var orgFile *model.OrgFile
+	agendaFiles, err := parser.ParseAgendaFiles(cfg.Files.AgendaFiles, loaded, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("loading agenda files: %w", err)
+	}
+	if len(agendaFiles.Items) == 0 {
+		if editing == nil {
+			return nil, fmt.Errorf("loading agenda files: no org files found in agenda_files")
+		}
+		return nil, nil
+	}
+	return agendaFiles, nil
+}
 
+// loadOrgFile parses the org file at filePath, or ./todo.org if it is empty. In
+// multi-file mode all org files in the directory of filePath are loaded instead,
+// including those in its subdirectories if recursive is set.
+func loadOrgFile(filePath string, multiMode, recursive bool, cfg *config.Config) (*model.OrgFile, error) {
 	if multiMode {
 		// Multi-file mode: load all .org files in directory
 		var dirPath string
@@ -81,46 +171,32 @@ func main() {
 			// Use current directory
 			cwd, err := os.Getwd()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
-				os.Exit(1)
+				return nil, fmt.Errorf("getting current directory: %w", err)
 			}
 			dirPath = cwd
 		}
 
-		orgFile, err = parser.ParseMultipleOrgFiles(dirPath, cfg)
+		orgFile, err := parser.ParseMultipleOrgFiles(dirPath, cfg, recursive)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing org files: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		// Single file mode (default)
-		if filePath == "" {
-			// Default to ./todo.org
-			cwd, err := os.Getwd()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
-				os.Exit(1)
-			}
-			filePath = filepath.Join(cwd, "todo.org")
+			return nil, fmt.Errorf("parsing org files: %w", err)
 		}
+		return orgFile, nil
+	}
 
-		// Parse the org file
-		orgFile, err = parser.ParseOrgFile(filePath, cfg)
+	// Single file mode (default)
+	if filePath == "" {
+		// Default to ./todo.org
+		cwd, err := os.Getwd()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing org file: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("getting current directory: %w", err)
 		}
+		filePath = filepath.Join(cwd, "todo.org")
 	}
 
-	// Run the UI
-	if err := ui.RunUI(orgFile, cfg, captureMode, captureText); err != nil {
-		fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Save on exit
-	if err := parser.Save(orgFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving file: %v\n", err)
-		os.Exit(1)
+	// Parse the org file
+	orgFile, err := parser.ParseOrgFile(filePath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parsing org file: %w", err)
 	}
+	return orgFile, nil
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/notify"
+)
+
+// runNotify runs the notification daemon over the agenda_files from the config,
+// or over the given file or directory:
+//
+//	org notify [-m] [-r] [file.org]
+func runNotify(args []string) {
+	flags := flag.NewFlagSet("notify", flag.ExitOnError)
+	multiMode := flags.Bool("m", false, "Watch all org files in the directory")
+	recursive := flags.Bool("r", false, "Watch the org files in subdirectories too, implies -m")
+	flags.Parse(args)
+
+	cfg := loadConfigOrDefault()
+	load := func() (*model.OrgFile, error) {
+		if flags.NArg() == 0 && len(cfg.Files.AgendaFiles) > 0 {
+			return loadAgendaFiles(nil, cfg)
+		}
+		return loadOrgFile(flags.Arg(0), *multiMode || *recursive, *recursive, cfg)
+	}
+
+	daemon, err := notify.New(load, notify.Sender(cfg.Notify.Command), cfg, notify.SystemClock)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	// Files that can't be loaded at the start are a mistake rather than an edit
+	if err := daemon.Step(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		os.Exit(1)
+	}
+	daemon.Run(func(err error) {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+	})
+}
@@ -11,11 +11,20 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	Keybindings KeybindingsConfig `toml:"keybindings"`
-	Colors      ColorsConfig      `toml:"colors"`
-	Tags        TagsConfig        `toml:"tags"`
-	States      StatesConfig      `toml:"states"`
-	UI          UIConfig          `toml:"ui"`
+	Keybindings  KeybindingsConfig  `toml:"keybindings"`
+	Colors       ColorsConfig       `toml:"colors"`
+	Tags         TagsConfig         `toml:"tags"`
+	States       StatesConfig       `toml:"states"`
+	UI           UIConfig           `toml:"ui"`
+	Effort       EffortConfig       `toml:"effort"`
+	Dependencies DependenciesConfig `toml:"dependencies"`
+	IDs          IDsConfig          `toml:"ids"`
+	Links        LinksConfig        `toml:"links"`
+	Babel        BabelConfig        `toml:"babel"`
+	Files        FilesConfig        `toml:"files"`
+	Clock        ClockConfig        `toml:"clock"`
+	Pomodoro     PomodoroConfig     `toml:"pomodoro"`
+	Notify       NotifyConfig       `toml:"notify"`
 }
 
 // KeybindingsConfig holds all keybinding configurations
@@ -49,6 +58,28 @@ type KeybindingsConfig struct {
 	Quit          []string `toml:"quit"`
 	Settings      []string `toml:"settings"`
 	TagItem       []string `toml:"tag_item"`
+	ToggleMark    []string `toml:"toggle_mark"`
+	ToggleVisual  []string `toml:"toggle_visual"`
+	ClearMarks    []string `toml:"clear_marks"`
+	Filter        []string `toml:"filter"`
+	ClearFilter   []string `toml:"clear_filter"`
+	Narrow        []string `toml:"narrow"`
+	Widen         []string `toml:"widen"`
+	SortItems     []string `toml:"sort_items"`
+	EffortReport  []string `toml:"effort_report"`
+	ColumnView    []string `toml:"column_view"`
+	AssignID      []string `toml:"assign_id"`
+	FollowLink    []string `toml:"follow_link"`
+	InsertLink    []string `toml:"insert_link"`
+	EditTable     []string `toml:"edit_table"`
+	ExecuteBlock  []string `toml:"execute_block"`
+	EditExternal  []string `toml:"edit_external"`
+	EditSubtree   []string `toml:"edit_subtree"`
+	NewFile       []string `toml:"new_file"`
+	MoveToFile    []string `toml:"move_to_file"`
+	JumpToClock   []string `toml:"jump_to_clock"`
+	ClockHistory  []string `toml:"clock_history"`
+	Pomodoro      []string `toml:"pomodoro"`
 }
 
 // ColorsConfig holds color configurations
@@ -64,6 +95,9 @@ type ColorsConfig struct {
 	Status    string `toml:"status"`
 	Note      string `toml:"note"`
 	Folded    string `toml:"folded"`
+	Marked    string `toml:"marked"`
+	Link      string `toml:"link"`
+	Code      string `toml:"code"`
 }
 
 // TagConfig represents a single tag configuration
@@ -83,6 +117,7 @@ type TagsConfig struct {
 type StateConfig struct {
 	Name  string `toml:"name"`
 	Color string `toml:"color"`
+	Done  bool   `toml:"done,omitempty"` // Whether the state is final, like DONE or CANCELLED; without any, the last state is
 }
 
 // StatesConfig holds TODO state configurations
@@ -99,6 +134,62 @@ type UIConfig struct {
 	OrgSyntaxHighlighting bool   `toml:"org_syntax_highlighting"`
 	ShowIndentationGuides bool   `toml:"show_indentation_guides"`
 	IndentationGuideColor string `toml:"indentation_guide_color"`
+	ColumnFormat          string `toml:"column_format"` // Default column view spec, in #+COLUMNS: syntax
+	Editor                string `toml:"editor"`        // External editor command, defaults to $VISUAL or $EDITOR
+}
+
+// EffortConfig holds effort estimate configurations
+type EffortConfig struct {
+	HoursPerDay float64 `toml:"hours_per_day"`
+	DaysPerWeek float64 `toml:"days_per_week"`
+}
+
+// DependenciesConfig holds task dependency configurations
+type DependenciesConfig struct {
+	UnblockDependents bool `toml:"unblock_dependents"` // Move BLOCK dependents back to the first state when their blockers are done
+}
+
+// IDsConfig holds item ID configurations
+type IDsConfig struct {
+	AutoCreate bool `toml:"auto_create"` // Add an :ID: property to every newly created item
+}
+
+// LinksConfig holds link configurations
+type LinksConfig struct {
+	Opener string `toml:"opener"` // Command used to open URLs and files; empty uses the system default
+}
+
+// BabelConfig holds source block execution configurations
+type BabelConfig struct {
+	Interpreters map[string]string `toml:"interpreters"` // Command run for each source block language; other languages cannot be run
+	Timeout      int               `toml:"timeout"`      // Seconds before a running block is stopped
+}
+
+// FilesConfig holds multi-file mode configurations
+type FilesConfig struct {
+	Include        []string `toml:"include"`         // Glob patterns for the files to load, matched against paths relative to the directory
+	Exclude        []string `toml:"exclude"`         // Glob patterns for files and directories to skip, like lines of .orgignore
+	IncludeArchive bool     `toml:"include_archive"` // Also load .org_archive files
+	AgendaFiles    []string `toml:"agenda_files"`    // Files and directories the agenda view gathers items from
+}
+
+// ClockConfig holds time tracking configurations
+type ClockConfig struct {
+	IdleMinutes int `toml:"idle_minutes"` // Minutes without a key press after which a running clock asks what to do with the idle time; 0 turns this off
+}
+
+// PomodoroConfig holds focus timer configurations
+type PomodoroConfig struct {
+	WorkMinutes   int    `toml:"work_minutes"`   // Length of a pomodoro
+	BreakMinutes  int    `toml:"break_minutes"`  // Length of the break after it
+	NotifyCommand string `toml:"notify_command"` // Command run with a message when a pomodoro or break ends; empty runs none
+}
+
+// NotifyConfig holds configurations of the notification daemon
+type NotifyConfig struct {
+	Command      string `toml:"command"`       // Command run with the message as its last argument, or a webhook URL to post to; empty prints the messages
+	LeadMinutes  *int   `toml:"lead_minutes"`  // Minutes before a timed scheduled item to notify; 0 notifies at the time
+	DeadlineTime string `toml:"deadline_time"` // Time of day (HH:MM) to notify of the deadlines of the day
 }
 
 // DefaultConfig returns the default configuration
@@ -134,6 +225,28 @@ func DefaultConfig() *Config {
 			Quit:          []string{"q", "ctrl+c"},
 			Settings:      []string{","},
 			TagItem:       []string{"#"},
+			ToggleMark:    []string{"m"},
+			ToggleVisual:  []string{"v"},
+			ClearMarks:    []string{"M"},
+			Filter:        []string{"f"},
+			ClearFilter:   []string{"F"},
+			Narrow:        []string{"n"},
+			Widen:         []string{"N"},
+			SortItems:     []string{"^"},
+			EffortReport:  []string{"E"},
+			ColumnView:    []string{"C"},
+			AssignID:      []string{"I"},
+			FollowLink:    []string{"g"},
+			InsertLink:    []string{"L"},
+			EditTable:     []string{"T"},
+			ExecuteBlock:  []string{"x"},
+			EditExternal:  []string{"ctrl+e"},
+			EditSubtree:   []string{"alt+e"},
+			NewFile:       []string{"ctrl+n"},
+			MoveToFile:    []string{"w"},
+			JumpToClock:   []string{"J"},
+			ClockHistory:  []string{"H"},
+			Pomodoro:      []string{"P"},
 		},
 		Colors: ColorsConfig{
 			Todo:      "202",
@@ -147,6 +260,9 @@ func DefaultConfig() *Config {
 			Status:    "241",
 			Note:      "246",
 			Folded:    "243",
+			Marked:    "213",
+			Link:      "75",
+			Code:      "180",
 		},
 		Tags: TagsConfig{
 			Enabled:    true,
@@ -174,6 +290,34 @@ func DefaultConfig() *Config {
 			OrgSyntaxHighlighting: true,
 			ShowIndentationGuides: true,
 			IndentationGuideColor: "245",
+			ColumnFormat:          "%40ITEM %TODO{X/} %PRIORITY %TAGS %EFFORT{:} %CLOCKSUM{:} %DEADLINE",
+		},
+		Effort: EffortConfig{
+			HoursPerDay: 8,
+			DaysPerWeek: 5,
+		},
+		Dependencies: DependenciesConfig{
+			UnblockDependents: true,
+		},
+		Babel: BabelConfig{
+			Interpreters: map[string]string{
+				"sh":     "sh",
+				"bash":   "bash",
+				"python": "python3",
+				"go":     "go run",
+			},
+			Timeout: 30,
+		},
+		Files: FilesConfig{
+			Include: []string{"*.org"},
+		},
+		Pomodoro: PomodoroConfig{
+			WorkMinutes:  25,
+			BreakMinutes: 5,
+		},
+		Notify: NotifyConfig{
+			LeadMinutes:  intPtr(10),
+			DeadlineTime: "09:00",
 		},
 	}
 }
@@ -191,6 +335,11 @@ func GetConfigPath() (string, error) {
 	return configPath, nil
 }
 
+// intPtr returns a pointer to an int, for settings where 0 differs from unset
+func intPtr(n int) *int {
+	return &n
+}
+
 // LoadConfig loads the configuration from the config file
 func LoadConfig() (*Config, error) {
 	configPath, err := GetConfigPath()
@@ -340,6 +489,72 @@ func (c *Config) fillDefaults() {
 	if len(c.Keybindings.TagItem) == 0 {
 		c.Keybindings.TagItem = defaults.Keybindings.TagItem
 	}
+	if len(c.Keybindings.ToggleMark) == 0 {
+		c.Keybindings.ToggleMark = defaults.Keybindings.ToggleMark
+	}
+	if len(c.Keybindings.ToggleVisual) == 0 {
+		c.Keybindings.ToggleVisual = defaults.Keybindings.ToggleVisual
+	}
+	if len(c.Keybindings.ClearMarks) == 0 {
+		c.Keybindings.ClearMarks = defaults.Keybindings.ClearMarks
+	}
+	if len(c.Keybindings.Filter) == 0 {
+		c.Keybindings.Filter = defaults.Keybindings.Filter
+	}
+	if len(c.Keybindings.ClearFilter) == 0 {
+		c.Keybindings.ClearFilter = defaults.Keybindings.ClearFilter
+	}
+	if len(c.Keybindings.Narrow) == 0 {
+		c.Keybindings.Narrow = defaults.Keybindings.Narrow
+	}
+	if len(c.Keybindings.Widen) == 0 {
+		c.Keybindings.Widen = defaults.Keybindings.Widen
+	}
+	if len(c.Keybindings.SortItems) == 0 {
+		c.Keybindings.SortItems = defaults.Keybindings.SortItems
+	}
+	if len(c.Keybindings.EffortReport) == 0 {
+		c.Keybindings.EffortReport = defaults.Keybindings.EffortReport
+	}
+	if len(c.Keybindings.ColumnView) == 0 {
+		c.Keybindings.ColumnView = defaults.Keybindings.ColumnView
+	}
+	if len(c.Keybindings.AssignID) == 0 {
+		c.Keybindings.AssignID = defaults.Keybindings.AssignID
+	}
+	if len(c.Keybindings.FollowLink) == 0 {
+		c.Keybindings.FollowLink = defaults.Keybindings.FollowLink
+	}
+	if len(c.Keybindings.InsertLink) == 0 {
+		c.Keybindings.InsertLink = defaults.Keybindings.InsertLink
+	}
+	if len(c.Keybindings.EditTable) == 0 {
+		c.Keybindings.EditTable = defaults.Keybindings.EditTable
+	}
+	if len(c.Keybindings.ExecuteBlock) == 0 {
+		c.Keybindings.ExecuteBlock = defaults.Keybindings.ExecuteBlock
+	}
+	if len(c.Keybindings.EditExternal) == 0 {
+		c.Keybindings.EditExternal = defaults.Keybindings.EditExternal
+	}
+	if len(c.Keybindings.EditSubtree) == 0 {
+		c.Keybindings.EditSubtree = defaults.Keybindings.EditSubtree
+	}
+	if len(c.Keybindings.NewFile) == 0 {
+		c.Keybindings.NewFile = defaults.Keybindings.NewFile
+	}
+	if len(c.Keybindings.MoveToFile) == 0 {
+		c.Keybindings.MoveToFile = defaults.Keybindings.MoveToFile
+	}
+	if len(c.Keybindings.JumpToClock) == 0 {
+		c.Keybindings.JumpToClock = defaults.Keybindings.JumpToClock
+	}
+	if len(c.Keybindings.ClockHistory) == 0 {
+		c.Keybindings.ClockHistory = defaults.Keybindings.ClockHistory
+	}
+	if len(c.Keybindings.Pomodoro) == 0 {
+		c.Keybindings.Pomodoro = defaults.Keybindings.Pomodoro
+	}
 
 	// Fill colors if empty
 	if c.Colors.Todo == "" {
@@ -375,6 +590,15 @@ func (c *Config) fillDefaults() {
 	if c.Colors.Folded == "" {
 		c.Colors.Folded = defaults.Colors.Folded
 	}
+	if c.Colors.Marked == "" {
+		c.Colors.Marked = defaults.Colors.Marked
+	}
+	if c.Colors.Link == "" {
+		c.Colors.Link = defaults.Colors.Link
+	}
+	if c.Colors.Code == "" {
+		c.Colors.Code = defaults.Colors.Code
+	}
 
 	// Fill tags if empty
 	if len(c.Tags.Tags) == 0 {
@@ -406,6 +630,38 @@ func (c *Config) fillDefaults() {
 	if c.UI.IndentationGuideColor == "" {
 		c.UI.IndentationGuideColor = defaults.UI.IndentationGuideColor
 	}
+	if c.UI.ColumnFormat == "" {
+		c.UI.ColumnFormat = defaults.UI.ColumnFormat
+	}
+
+	// Fill effort units if zero values
+	if c.Effort.HoursPerDay <= 0 {
+		c.Effort.HoursPerDay = defaults.Effort.HoursPerDay
+	}
+	if c.Effort.DaysPerWeek <= 0 {
+		c.Effort.DaysPerWeek = defaults.Effort.DaysPerWeek
+	}
+	if len(c.Babel.Interpreters) == 0 {
+		c.Babel.Interpreters = defaults.Babel.Interpreters
+	}
+	if c.Babel.Timeout <= 0 {
+		c.Babel.Timeout = defaults.Babel.Timeout
+	}
+	if len(c.Files.Include) == 0 {
+		c.Files.Include = defaults.Files.Include
+	}
+	if c.Pomodoro.WorkMinutes <= 0 {
+		c.Pomodoro.WorkMinutes = defaults.Pomodoro.WorkMinutes
+	}
+	if c.Pomodoro.BreakMinutes <= 0 {
+		c.Pomodoro.BreakMinutes = defaults.Pomodoro.BreakMinutes
+	}
+	if c.Notify.LeadMinutes == nil {
+		c.Notify.LeadMinutes = defaults.Notify.LeadMinutes
+	}
+	if c.Notify.DeadlineTime == "" {
+		c.Notify.DeadlineTime = defaults.Notify.DeadlineTime
+	}
 }
 
 // BuildKeyBinding creates a key.Binding from config
@@ -511,6 +767,25 @@ func (c *Config) GetStateNames() []string {
 	return names
 }
 
+// IsDoneState returns true if a state is final. These are the states marked
+// done, or the last state if none are.
+func (c *Config) IsDoneState(name string) bool {
+	marked := false
+	for _, state := range c.States.States {
+		if state.Done {
+			marked = true
+			if state.Name == name {
+				return true
+			}
+		}
+	}
+	if marked {
+		return false
+	}
+	names := c.GetStateNames()
+	return len(names) > 0 && name == names[len(names)-1]
+}
+
 // UpdateKeybinding updates a keybinding in the configuration
 func (c *Config) UpdateKeybinding(action string, keys []string) error {
 	// Use reflection would be complex, so we handle specific cases
@@ -549,6 +824,50 @@ func (c *Config) UpdateKeybinding(action string, keys []string) error {
 		c.Keybindings.Help = keys
 	case "quit":
 		c.Keybindings.Quit = keys
+	case "toggle_mark":
+		c.Keybindings.ToggleMark = keys
+	case "toggle_visual":
+		c.Keybindings.ToggleVisual = keys
+	case "clear_marks":
+		c.Keybindings.ClearMarks = keys
+	case "filter":
+		c.Keybindings.Filter = keys
+	case "clear_filter":
+		c.Keybindings.ClearFilter = keys
+	case "narrow":
+		c.Keybindings.Narrow = keys
+	case "widen":
+		c.Keybindings.Widen = keys
+	case "sort_items":
+		c.Keybindings.SortItems = keys
+	case "effort_report":
+		c.Keybindings.EffortReport = keys
+	case "column_view":
+		c.Keybindings.ColumnView = keys
+	case "assign_id":
+		c.Keybindings.AssignID = keys
+	case "follow_link":
+		c.Keybindings.FollowLink = keys
+	case "insert_link":
+		c.Keybindings.InsertLink = keys
+	case "edit_table":
+		c.Keybindings.EditTable = keys
+	case "execute_block":
+		c.Keybindings.ExecuteBlock = keys
+	case "edit_external":
+		c.Keybindings.EditExternal = keys
+	case "edit_subtree":
+		c.Keybindings.EditSubtree = keys
+	case "new_file":
+		c.Keybindings.NewFile = keys
+	case "move_to_file":
+		c.Keybindings.MoveToFile = keys
+	case "jump_to_clock":
+		c.Keybindings.JumpToClock = keys
+	case "clock_history":
+		c.Keybindings.ClockHistory = keys
+	case "pomodoro":
+		c.Keybindings.Pomodoro = keys
 	default:
 		return fmt.Errorf("unknown action: %s", action)
 	}
@@ -587,6 +906,28 @@ func (c *Config) GetAllKeybindings() map[string][]string {
 		"quit":            c.Keybindings.Quit,
 		"settings":        c.Keybindings.Settings,
 		"tag_item":        c.Keybindings.TagItem,
+		"toggle_mark":     c.Keybindings.ToggleMark,
+		"toggle_visual":   c.Keybindings.ToggleVisual,
+		"clear_marks":     c.Keybindings.ClearMarks,
+		"filter":          c.Keybindings.Filter,
+		"clear_filter":    c.Keybindings.ClearFilter,
+		"narrow":          c.Keybindings.Narrow,
+		"widen":           c.Keybindings.Widen,
+		"sort_items":      c.Keybindings.SortItems,
+		"effort_report":   c.Keybindings.EffortReport,
+		"column_view":     c.Keybindings.ColumnView,
+		"assign_id":       c.Keybindings.AssignID,
+		"follow_link":     c.Keybindings.FollowLink,
+		"insert_link":     c.Keybindings.InsertLink,
+		"edit_table":      c.Keybindings.EditTable,
+		"execute_block":   c.Keybindings.ExecuteBlock,
+		"edit_external":   c.Keybindings.EditExternal,
+		"edit_subtree":    c.Keybindings.EditSubtree,
+		"new_file":        c.Keybindings.NewFile,
+		"move_to_file":    c.Keybindings.MoveToFile,
+		"jump_to_clock":   c.Keybindings.JumpToClock,
+		"clock_history":   c.Keybindings.ClockHistory,
+		"pomodoro":        c.Keybindings.Pomodoro,
 	}
 }
 
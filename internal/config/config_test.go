@@ -0,0 +1,27 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestNotifyLeadMinutes(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want int
+	}{
+		{"", 10},
+		{"[notify]\nlead_minutes = 0\n", 0},
+		{"[notify]\nlead_minutes = 5\n", 5},
+	} {
+		var cfg Config
+		if _, err := toml.Decode(tt.text, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		cfg.fillDefaults()
+		if cfg.Notify.LeadMinutes == nil || *cfg.Notify.LeadMinutes != tt.want {
+			t.Errorf("lead_minutes from %q = %v, want %d", tt.text, cfg.Notify.LeadMinutes, tt.want)
+		}
+	}
+}
@@ -0,0 +1,678 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// pageCSS styles the exported page; colors from the config are added inline
+const pageCSS = `
+body { font-family: system-ui, -apple-system, sans-serif; max-width: 960px; margin: 2em auto; padding: 0 1em; color: #222; line-height: 1.5; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .2em; margin-top: 1.5em; }
+.badge { display: inline-block; padding: 0 .45em; border-radius: 3px; font-size: .75em; font-weight: bold; vertical-align: middle; }
+.item { margin-left: 1.2em; }
+.outline > .item { margin-left: 0; }
+summary { cursor: pointer; }
+.leaf { padding-left: 1.1em; }
+.level-1 > summary, .level-1.leaf { font-size: 1.15em; font-weight: 600; }
+.done > summary .title, .done.leaf .title { text-decoration: line-through; opacity: .6; }
+.planning { font-size: .85em; margin-left: .5em; }
+.notes { margin: .2em 0 .6em 1.2em; }
+.notes p { margin: .4em 0; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { border: 1px solid #ccc; padding: .2em .6em; }
+td.num { text-align: right; }
+pre { padding: .6em; overflow-x: auto; background: #f6f6f6; }
+blockquote { border-left: 3px solid #ccc; margin: .5em 0; padding-left: 1em; color: #555; }
+.center { text-align: center; }
+.agenda h3 { font-size: 1em; margin: 1em 0 .3em; }
+.agenda td { border: none; padding: .1em .6em .1em 0; }
+footer { margin-top: 3em; font-size: .8em; color: #888; }
+`
+
+var (
+	htmlFormatter = chromahtml.New(chromahtml.WithClasses(true))
+	htmlCodeStyle = styles.Get("monokai")
+	slugPattern   = regexp.MustCompile(`[^a-z0-9]+`)
+	titleKeyword  = regexp.MustCompile(`(?i)^\s*#\+TITLE:\s*(.*)$`)
+)
+
+// priorityColors match the priority colors of the list view
+var priorityColors = map[model.Priority]string{
+	model.PriorityA: "196",
+	model.PriorityB: "214",
+	model.PriorityC: "226",
+}
+
+// htmlExporter holds what is needed to render the items of one file
+type htmlExporter struct {
+	cfg     *config.Config
+	now     time.Time
+	b       strings.Builder
+	anchors map[*model.Item]string // Element IDs of the headings
+	targets map[string]string      // Anchors by link target: id:, # and heading links
+}
+
+// HTML writes an org file as a single self-contained HTML page: an agenda of the
+// coming days followed by the outline with collapsible headings. States,
+// priorities and tags are shown as badges in the configured colors, and source
+// blocks are highlighted.
+func HTML(w io.Writer, orgFile *model.OrgFile, cfg *config.Config, now time.Time) error {
+	e := &htmlExporter{
+		cfg:     cfg,
+		now:     now,
+		anchors: make(map[*model.Item]string),
+		targets: make(map[string]string),
+	}
+	e.buildAnchors(orgFile.Items)
+
+	title := strings.TrimSuffix(filepath.Base(orgFile.Path), filepath.Ext(orgFile.Path))
+	for _, line := range orgFile.Preamble {
+		if parts := titleKeyword.FindStringSubmatch(line); parts != nil {
+			title = parts[1]
+		}
+	}
+
+	e.b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	e.b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&e.b, "<title>%s</title>\n<style>%s", html.EscapeString(title), pageCSS)
+	fmt.Fprintf(&e.b, "h1 { color: %s; }\na { color: %s; }\ncode { color: %s; }\n",
+		cssColor(cfg.Colors.Title), cssColor(cfg.Colors.Link), cssColor(cfg.Colors.Code))
+	if err := htmlFormatter.WriteCSS(&e.b, htmlCodeStyle); err != nil {
+		return err
+	}
+	e.b.WriteString("</style>\n</head>\n<body>\n")
+	fmt.Fprintf(&e.b, "<h1>%s</h1>\n", html.EscapeString(title))
+
+	e.writeAgenda(orgFile.Items)
+
+	e.b.WriteString("<section class=\"outline\">\n<h2>Outline</h2>\n")
+	e.writeNotes(orgFile.Preamble)
+	for _, item := range orgFile.Items {
+		e.writeItem(item)
+	}
+	e.b.WriteString("</section>\n")
+
+	fmt.Fprintf(&e.b, "<footer>Exported %s</footer>\n</body>\n</html>\n", now.Format("2006-01-02 15:04"))
+	_, err := io.WriteString(w, e.b.String())
+	return err
+}
+
+// buildAnchors gives every heading an element ID and records the link targets
+// that lead to it
+func (e *htmlExporter) buildAnchors(items []*model.Item) {
+	used := make(map[string]bool)
+	for _, item := range model.FlattenAllItems(items) {
+		id := item.GetProperty("ID")
+		customID := item.GetProperty("CUSTOM_ID")
+
+		base := slug(item.Title)
+		if customID != "" {
+			base = slug(customID)
+		} else if id != "" {
+			base = slug(id)
+		}
+		anchor := base
+		for n := 2; used[anchor]; n++ {
+			anchor = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[anchor] = true
+		e.anchors[item] = anchor
+
+		if id != "" {
+			e.targets["id:"+id] = anchor
+		}
+		if customID != "" {
+			e.targets["#"+customID] = anchor
+		}
+		if _, ok := e.targets["*"+item.Title]; !ok {
+			e.targets["*"+item.Title] = anchor
+		}
+	}
+}
+
+// slug turns text into a readable element ID
+func slug(text string) string {
+	s := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if s == "" {
+		return "item"
+	}
+	return s
+}
+
+// agendaEntry is a scheduled date or deadline shown in the agenda
+type agendaEntry struct {
+	item *model.Item
+	kind string
+	date time.Time
+}
+
+// writeAgenda writes the items scheduled or due in the configured number of days,
+// with the open items that are overdue
+func (e *htmlExporter) writeAgenda(items []*model.Item) {
+	startOfDay := time.Date(e.now.Year(), e.now.Month(), e.now.Day(), 0, 0, 0, 0, e.now.Location())
+	days := e.cfg.UI.AgendaDays
+	end := startOfDay.AddDate(0, 0, days)
+
+	var overdue []agendaEntry
+	byDay := make([][]agendaEntry, days)
+	for _, item := range model.FlattenAllItems(items) {
+		for _, entry := range []agendaEntry{{item, "Scheduled", timeOrZero(item.Scheduled)}, {item, "Deadline", timeOrZero(item.Deadline)}} {
+			switch {
+			case entry.date.IsZero() || !entry.date.Before(end):
+			case entry.date.Before(startOfDay):
+				if !e.cfg.IsDoneState(string(item.State)) {
+					overdue = append(overdue, entry)
+				}
+			default:
+				date := time.Date(entry.date.Year(), entry.date.Month(), entry.date.Day(), 0, 0, 0, 0, startOfDay.Location())
+				// Days are not all 24 hours long when daylight saving time changes
+				day := int(math.Round(date.Sub(startOfDay).Hours() / 24))
+				byDay[day] = append(byDay[day], entry)
+			}
+		}
+	}
+
+	fmt.Fprintf(&e.b, "<section class=\"agenda\">\n<h2>Agenda (next %d days)</h2>\n", days)
+	empty := len(overdue) == 0
+	if len(overdue) > 0 {
+		e.writeAgendaDay("Overdue", overdue)
+	}
+	for i, entries := range byDay {
+		if len(entries) > 0 {
+			empty = false
+			e.writeAgendaDay(parser.FormatOrgDate(startOfDay.AddDate(0, 0, i)), entries)
+		}
+	}
+	if empty {
+		e.b.WriteString("<p>Nothing scheduled.</p>\n")
+	}
+	e.b.WriteString("</section>\n")
+}
+
+func (e *htmlExporter) writeAgendaDay(heading string, entries []agendaEntry) {
+	fmt.Fprintf(&e.b, "<h3>%s</h3>\n<table>\n", html.EscapeString(heading))
+	for _, entry := range entries {
+		color := e.cfg.Colors.Scheduled
+		if entry.date.Before(e.now) && !e.cfg.IsDoneState(string(entry.item.State)) {
+			color = e.cfg.Colors.Overdue
+		}
+		fmt.Fprintf(&e.b, "<tr><td style=\"color: %s\">%s: %s</td><td>%s<a href=\"#%s\">%s</a>%s</td></tr>\n",
+			cssColor(color), entry.kind, parser.FormatOrgDate(entry.date),
+			e.stateBadge(entry.item), e.anchors[entry.item], e.inline(entry.item.Title), e.tagBadges(entry.item))
+	}
+	e.b.WriteString("</table>\n")
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// writeItem writes an item with its notes and children as a collapsible section
+func (e *htmlExporter) writeItem(item *model.Item) {
+	class := fmt.Sprintf("item level-%d", item.Level)
+	if e.cfg.IsDoneState(string(item.State)) {
+		class += " done"
+	}
+
+	notes := e.renderNotes(item.Notes)
+	if notes == "" && len(item.Children) == 0 {
+		fmt.Fprintf(&e.b, "<div class=\"%s leaf\" id=\"%s\">%s</div>\n", class, e.anchors[item], e.heading(item))
+		return
+	}
+
+	open := " open"
+	if item.Folded {
+		open = ""
+	}
+	fmt.Fprintf(&e.b, "<details class=\"%s\" id=\"%s\"%s>\n<summary>%s</summary>\n", class, e.anchors[item], open, e.heading(item))
+	if notes != "" {
+		fmt.Fprintf(&e.b, "<div class=\"notes\">\n%s</div>\n", notes)
+	}
+	for _, child := range item.Children {
+		e.writeItem(child)
+	}
+	e.b.WriteString("</details>\n")
+}
+
+// heading renders an item's heading line with its badges and dates
+func (e *htmlExporter) heading(item *model.Item) string {
+	var b strings.Builder
+	b.WriteString(e.stateBadge(item))
+	if item.Priority != model.PriorityNone {
+		b.WriteString(badge("#"+string(item.Priority), priorityColors[item.Priority]))
+		b.WriteString(" ")
+	}
+	b.WriteString("<span class=\"title\">" + e.inline(item.Title) + "</span>")
+	b.WriteString(e.tagBadges(item))
+
+	done := e.cfg.IsDoneState(string(item.State))
+	date := func(label string, t *time.Time, format string, checkOverdue bool) {
+		if t == nil {
+			return
+		}
+		color := e.cfg.Colors.Scheduled
+		if checkOverdue && !done && t.Before(e.now) {
+			color = e.cfg.Colors.Overdue
+		}
+		fmt.Fprintf(&b, "<span class=\"planning\" style=\"color: %s\">%s: %s</span>", cssColor(color), label, t.Format(format))
+	}
+	date("Scheduled", item.Scheduled, "2006-01-02 Mon", true)
+	date("Deadline", item.Deadline, "2006-01-02 Mon", true)
+	date("Closed", item.Closed, "2006-01-02 Mon 15:04", false)
+	if effort := item.GetEffort(); effort != "" {
+		fmt.Fprintf(&b, "<span class=\"planning\" style=\"color: %s\">Effort: %s</span>", cssColor(e.cfg.Colors.Status), html.EscapeString(effort))
+	}
+	return b.String()
+}
+
+func (e *htmlExporter) stateBadge(item *model.Item) string {
+	if item.State == model.StateNone {
+		return ""
+	}
+	return badge(string(item.State), e.cfg.GetStateColor(string(item.State))) + " "
+}
+
+func (e *htmlExporter) tagBadges(item *model.Item) string {
+	var b strings.Builder
+	for _, tag := range item.Tags {
+		b.WriteString(" " + badge(tag, e.cfg.GetTagColor(tag)))
+	}
+	return b.String()
+}
+
+// badge renders a label on a background of the given configured color
+func badge(label, color string) string {
+	background := cssColor(color)
+	return fmt.Sprintf("<span class=\"badge\" style=\"background: %s; color: %s\">%s</span>",
+		background, textColor(background), html.EscapeString(label))
+}
+
+// writeNotes writes notes outside of any item, such as the text before the first heading
+func (e *htmlExporter) writeNotes(notes []string) {
+	if rendered := e.renderNotes(notes); rendered != "" {
+		fmt.Fprintf(&e.b, "<div class=\"notes\">\n%s</div>\n", rendered)
+	}
+}
+
+// htmlList is a list that is open while rendering notes
+type htmlList struct {
+	indent int
+	tag    string // ul or ol
+}
+
+// notesRenderer turns notes into HTML, keeping track of open paragraphs and lists
+type notesRenderer struct {
+	e         *htmlExporter
+	b         strings.Builder
+	paragraph []string
+	lists     []htmlList
+}
+
+// renderNotes renders the notes under a heading. Drawers, planning lines,
+// keywords and comments are left out.
+func (e *htmlExporter) renderNotes(notes []string) string {
+	n := &notesRenderer{e: e}
+	n.render(model.Dedent(notes))
+	return n.b.String()
+}
+
+func (n *notesRenderer) render(lines []string) {
+	tables := make(map[int]*model.Table)
+	for _, table := range model.FindTables(lines) {
+		tables[table.Start] = table
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		if strings.TrimSpace(line) == "" {
+			n.flushParagraph()
+			continue
+		}
+
+		if table, ok := tables[i]; ok {
+			n.startBlock(indent)
+			n.table(table)
+			i = table.End - 1
+			continue
+		}
+
+		if markdownFencePrefix.MatchString(line) {
+			n.startBlock(indent)
+			language := strings.TrimLeft(strings.TrimSpace(line), "`~")
+			var code []string
+			for i++; i < len(lines) && !markdownFencePrefix.MatchString(lines[i]); i++ {
+				code = append(code, lines[i])
+			}
+			n.b.WriteString(highlightHTML(strings.Join(model.Dedent(code), "\n"), language))
+			continue
+		}
+
+		if name, ok := model.BlockBegin(line); ok {
+			if end := model.BlockEndIndex(lines, i, name); end >= 0 {
+				n.startBlock(indent)
+				n.block(name, line, lines[i+1:end])
+				i = end
+				continue
+			}
+		}
+
+		switch {
+		case orgDrawerStart.MatchString(line):
+			for i < len(lines) && !orgDrawerEnd.MatchString(lines[i]) {
+				i++
+			}
+		case orgFixedWidth.MatchString(line):
+			n.startBlock(indent)
+			var fixed []string
+			for ; i < len(lines) && orgFixedWidth.MatchString(lines[i]); i++ {
+				fixed = append(fixed, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ":"), " "))
+			}
+			i--
+			n.b.WriteString("<pre>" + html.EscapeString(strings.Join(fixed, "\n")) + "</pre>\n")
+		case isPlanningLine(line), orgKeywordPattern.MatchString(line), orgCommentPattern.MatchString(line):
+		case orgRulePattern.MatchString(line):
+			n.startBlock(indent)
+			n.b.WriteString("<hr>\n")
+		default:
+			n.text(line, indent)
+		}
+	}
+	n.flushParagraph()
+	n.closeLists(0)
+}
+
+// text adds a list item or a line of a paragraph
+func (n *notesRenderer) text(line string, indent int) {
+	parts := orgListItemPattern.FindStringSubmatch(line)
+	if parts == nil || (parts[2] == "*" && indent == 0) {
+		n.closeLists(indent)
+		n.paragraph = append(n.paragraph, strings.TrimSpace(line))
+		return
+	}
+
+	n.flushParagraph()
+	n.closeLists(indent + 1)
+	tag := "ul"
+	if strings.ContainsAny(parts[2], "0123456789") {
+		tag = "ol"
+	}
+	if len(n.lists) > 0 && n.lists[len(n.lists)-1].indent == indent {
+		if n.lists[len(n.lists)-1].tag == tag {
+			n.b.WriteString("</li>\n<li>")
+		} else {
+			n.closeLists(indent)
+		}
+	}
+	if len(n.lists) == 0 || n.lists[len(n.lists)-1].indent < indent {
+		n.lists = append(n.lists, htmlList{indent: indent, tag: tag})
+		n.b.WriteString("<" + tag + ">\n<li>")
+	}
+
+	switch parts[3] {
+	case "":
+	case "x", "X":
+		n.b.WriteString(`<input type="checkbox" checked disabled> `)
+	default:
+		n.b.WriteString(`<input type="checkbox" disabled> `)
+	}
+	text := parts[4]
+	if desc := orgDescPattern.FindStringSubmatch(text); desc != nil && tag == "ul" && parts[3] == "" {
+		n.b.WriteString("<b>" + n.e.inline(desc[1]) + "</b>: ")
+		text = desc[2]
+	}
+	n.paragraph = append(n.paragraph, text)
+}
+
+// startBlock ends the paragraph and the lists the block at the indentation is not part of
+func (n *notesRenderer) startBlock(indent int) {
+	n.flushParagraph()
+	n.closeLists(indent)
+}
+
+func (n *notesRenderer) flushParagraph() {
+	if len(n.paragraph) == 0 {
+		return
+	}
+	text := n.e.inline(strings.Join(n.paragraph, " "))
+	n.paragraph = nil
+	// Text directly in a list item needs no paragraph
+	if len(n.lists) > 0 {
+		n.b.WriteString(text + "\n")
+	} else {
+		n.b.WriteString("<p>" + text + "</p>\n")
+	}
+}
+
+// closeLists closes the lists indented at least as far as indent
+func (n *notesRenderer) closeLists(indent int) {
+	for len(n.lists) > 0 && n.lists[len(n.lists)-1].indent >= indent {
+		n.flushParagraph()
+		n.b.WriteString("</li>\n</" + n.lists[len(n.lists)-1].tag + ">\n")
+		n.lists = n.lists[:len(n.lists)-1]
+	}
+}
+
+// block renders an org block with the given content
+func (n *notesRenderer) block(name, begin string, content []string) {
+	fields := strings.Fields(begin)
+	switch name {
+	case "SRC":
+		language := ""
+		if len(fields) > 1 {
+			language = strings.ToLower(fields[1])
+		}
+		n.b.WriteString(highlightHTML(strings.Join(model.Dedent(content), "\n"), language))
+	case "QUOTE", "CENTER":
+		class := ""
+		if name == "CENTER" {
+			class = ` class="center"`
+		}
+		fmt.Fprintf(&n.b, "<blockquote%s>\n%s</blockquote>\n", class, n.e.renderNotes(content))
+	case "VERSE":
+		var verse []string
+		for _, line := range model.Dedent(content) {
+			verse = append(verse, n.e.inline(line))
+		}
+		n.b.WriteString("<blockquote>" + strings.Join(verse, "<br>\n") + "</blockquote>\n")
+	case "COMMENT":
+	case "EXPORT":
+		// Only text meant for HTML can be kept as it is
+		if len(fields) > 1 && strings.ToLower(fields[1]) == "html" {
+			n.b.WriteString(strings.Join(model.Dedent(content), "\n") + "\n")
+		}
+	case model.DynamicBlock:
+		n.b.WriteString(n.e.renderNotes(content))
+	default:
+		n.b.WriteString("<pre>" + html.EscapeString(strings.Join(model.Dedent(content), "\n")) + "</pre>\n")
+	}
+}
+
+// table renders an org table, with the first row as the header if a rule follows it
+func (n *notesRenderer) table(table *model.Table) {
+	width := table.Width()
+	row := func(cells []string, tag string) {
+		n.b.WriteString("<tr>")
+		for col := 0; col < width; col++ {
+			cell := ""
+			if col < len(cells) {
+				cell = cells[col]
+			}
+			class := ""
+			if tag == "td" && table.IsNumericColumn(col) {
+				class = ` class="num"`
+			}
+			fmt.Fprintf(&n.b, "<%s%s>%s</%s>", tag, class, n.e.inline(cell), tag)
+		}
+		n.b.WriteString("</tr>\n")
+	}
+
+	header, rows := tableRows(table)
+	n.b.WriteString("<table>\n")
+	if header != nil {
+		n.b.WriteString("<thead>\n")
+		row(header, "th")
+		n.b.WriteString("</thead>\n")
+	}
+	n.b.WriteString("<tbody>\n")
+	for _, cells := range rows {
+		row(cells, "td")
+	}
+	n.b.WriteString("</tbody>\n</table>\n")
+}
+
+// inline renders org links and inline markup in a line as HTML
+func (e *htmlExporter) inline(text string) string {
+	c := &inlineConverter{}
+	return c.restore(e.inlineHeld(c, text))
+}
+
+// inlineHeld renders org links and inline markup in a line as HTML, leaving the
+// rendered parts held
+func (e *htmlExporter) inlineHeld(c *inlineConverter, text string) string {
+	text = orgLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		parts := orgLinkPattern.FindStringSubmatch(link)
+		return c.hold(e.link(parts[1], parts[2]))
+	})
+	text = c.replaceEmphasis(text, func(marker rune, inner string) string {
+		switch marker {
+		case '*':
+			return "<b>" + e.inlineHeld(c, inner) + "</b>"
+		case '/':
+			return "<i>" + e.inlineHeld(c, inner) + "</i>"
+		case '_':
+			return "<u>" + e.inlineHeld(c, inner) + "</u>"
+		case '+':
+			return "<del>" + e.inlineHeld(c, inner) + "</del>"
+		default: // = and ~
+			return "<code>" + html.EscapeString(inner) + "</code>"
+		}
+	})
+
+	// Escape the text between the converted parts
+	var b strings.Builder
+	last := 0
+	for _, loc := range heldPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
+
+// link renders an org link. Links to headings and IDs lead to the heading on the
+// page; links to headings that are not exported become plain text.
+func (e *htmlExporter) link(target, description string) string {
+	var href string
+	switch {
+	case strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:"):
+		href = target
+	case strings.HasPrefix(target, "file:"):
+		href = strings.TrimPrefix(target, "file:")
+		if i := strings.Index(href, "::"); i >= 0 {
+			href = href[:i]
+		}
+	default:
+		key := target
+		if !strings.HasPrefix(key, "id:") && !strings.HasPrefix(key, "#") && !strings.HasPrefix(key, "*") {
+			key = "*" + key
+		}
+		if description == "" {
+			description = strings.TrimPrefix(strings.TrimLeft(target, "*#"), "id:")
+		}
+		anchor, ok := e.targets[key]
+		if !ok {
+			return e.inline(description)
+		}
+		href = "#" + anchor
+	}
+
+	if description == "" {
+		description = target
+	}
+	return fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(href), e.inline(description))
+}
+
+// highlightHTML highlights code with chroma, or shows it plain if the language
+// is unknown
+func highlightHTML(code, language string) string {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		return "<pre>" + html.EscapeString(code) + "</pre>\n"
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return "<pre>" + html.EscapeString(code) + "</pre>\n"
+	}
+	var b strings.Builder
+	if err := htmlFormatter.Format(&b, htmlCodeStyle, iterator); err != nil {
+		return "<pre>" + html.EscapeString(code) + "</pre>\n"
+	}
+	return b.String() + "\n"
+}
+
+// ansiBasicColors are the usual values of the first 16 ANSI colors
+var ansiBasicColors = [16]string{
+	"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+	"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// cssColor converts a configured color, an ANSI 256 color code or a hex color, to CSS
+func cssColor(color string) string {
+	if strings.HasPrefix(color, "#") {
+		return color
+	}
+	n, err := strconv.Atoi(color)
+	if err != nil || n < 0 || n > 255 {
+		return "#888888"
+	}
+
+	var r, g, b int
+	switch {
+	case n < 16:
+		return ansiBasicColors[n]
+	case n < 232:
+		// 6x6x6 color cube
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		r, g, b = levels[n/36], levels[n/6%6], levels[n%6]
+	default:
+		// Grayscale ramp
+		r = 8 + 10*(n-232)
+		g, b = r, r
+	}
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// textColor returns black or white, whichever reads better on the background
+func textColor(background string) string {
+	var r, g, b int
+	if _, err := fmt.Sscanf(background, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return "#ffffff"
+	}
+	if 0.299*float64(r)+0.587*float64(g)+0.114*float64(b) > 150 {
+		return "#000000"
+	}
+	return "#ffffff"
+}
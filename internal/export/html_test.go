@@ -0,0 +1,39 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+func TestHTMLDoneStates(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.States.States = []config.StateConfig{
+		{Name: "TODO"},
+		{Name: "DONE", Done: true},
+		{Name: "CANCELLED", Done: true},
+		{Name: "WAITING"},
+	}
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
+	yesterday := now.AddDate(0, 0, -1)
+	orgFile := &model.OrgFile{Items: []*model.Item{
+		{Level: 1, State: "CANCELLED", Title: "Dropped", Deadline: &yesterday},
+		{Level: 1, State: "WAITING", Title: "Pending", Deadline: &yesterday},
+	}}
+
+	var b strings.Builder
+	if err := HTML(&b, orgFile, cfg, now); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	agenda := page[:strings.Index(page, `class="outline"`)]
+	if strings.Contains(agenda, "Dropped") || !strings.Contains(agenda, "Pending") {
+		t.Errorf("agenda should list only the overdue open item:\n%s", agenda)
+	}
+	if !strings.Contains(page, `class="item level-1 done leaf"`) {
+		t.Errorf("CANCELLED item not marked done:\n%s", page)
+	}
+}
@@ -0,0 +1,669 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// markdownFencePrefix matches the start and end of a Markdown code fence
+var markdownFencePrefix = regexp.MustCompile("^\\s*(```|~~~)")
+
+// markdownInline converts org links and inline markup in a line to Markdown
+func markdownInline(text string) string {
+	c := &inlineConverter{}
+	return c.restore(c.markdown(text))
+}
+
+// markdown converts org links and inline markup in a line to Markdown, leaving
+// the converted parts held
+func (c *inlineConverter) markdown(text string) string {
+	text = orgLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		parts := orgLinkPattern.FindStringSubmatch(link)
+		return c.hold(markdownLink(parts[1], parts[2]))
+	})
+
+	text = c.replaceEmphasis(text, func(marker rune, inner string) string {
+		switch marker {
+		case '*':
+			return "**" + c.markdown(inner) + "**"
+		case '/':
+			return "*" + c.markdown(inner) + "*"
+		case '+':
+			return "~~" + c.markdown(inner) + "~~"
+		case '_':
+			return "<ins>" + c.markdown(inner) + "</ins>"
+		default: // = and ~
+			return "`" + inner + "`"
+		}
+	})
+
+	// Escape the text between the converted parts
+	var b strings.Builder
+	last := 0
+	for _, loc := range heldPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(markdownEscape(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(markdownEscape(text[last:]))
+	return b.String()
+}
+
+// markdownEscape escapes the characters of plain text that Markdown would read
+// as markup, so text like 2*3*4 is imported back as it was. Underscores inside
+// words are left alone, as they don't emphasize.
+func markdownEscape(text string) string {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	runes := []rune(text)
+	var b strings.Builder
+	for i, r := range runes {
+		switch r {
+		case '*', '`':
+			b.WriteRune('\\')
+		case '_':
+			if i == 0 || i+1 == len(runes) || !isWord(runes[i-1]) || !isWord(runes[i+1]) {
+				b.WriteRune('\\')
+			}
+		case '\\':
+			// A backslash only escapes the punctuation after it
+			if next := i + 1; next < len(runes) && runes[next] <= unicode.MaxASCII &&
+				(unicode.IsPunct(runes[next]) || unicode.IsSymbol(runes[next])) {
+				b.WriteRune('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// markdownLink converts an org link. Links to headings and IDs only work inside
+// org, so they become their description.
+func markdownLink(target, description string) string {
+	var url string
+	switch {
+	case strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:"):
+		url = target
+	case strings.HasPrefix(target, "file:"):
+		url = strings.TrimPrefix(target, "file:")
+		if i := strings.Index(url, "::"); i >= 0 {
+			url = url[:i]
+		}
+		if description == "" {
+			description = url
+		}
+	default:
+		if description == "" {
+			description = strings.TrimPrefix(strings.TrimLeft(target, "*#"), "id:")
+		}
+		return markdownInline(description)
+	}
+
+	if strings.ContainsAny(url, " ()") {
+		url = "<" + url + ">"
+	}
+	if description == "" {
+		return "<" + strings.Trim(url, "<>") + ">"
+	}
+	return "[" + markdownInline(description) + "](" + url + ")"
+}
+
+// Markdown writes an org file as GitHub-flavored Markdown. Headings keep their
+// level, with the state in bold, the priority as [#A] and the tags as a code span
+// after the title. Planning lines are kept as they are written in org, while
+// drawers, keywords and comments are left out.
+func Markdown(w io.Writer, orgFile *model.OrgFile) error {
+	var sections [][]string
+	if preamble := markdownNotes(orgFile.Preamble); len(preamble) > 0 {
+		sections = append(sections, preamble)
+	}
+	for _, item := range orgFile.Items {
+		sections = appendMarkdownItem(sections, item)
+	}
+
+	writer := bufio.NewWriter(w)
+	for i, section := range sections {
+		if i > 0 {
+			writer.WriteString("\n")
+		}
+		for _, line := range section {
+			if _, err := writer.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// appendMarkdownItem adds the sections for an item and its children
+func appendMarkdownItem(sections [][]string, item *model.Item) [][]string {
+	sections = append(sections, []string{markdownHeading(item)})
+
+	var planning []string
+	if item.Closed != nil {
+		planning = append(planning, fmt.Sprintf("CLOSED: [%s]", item.Closed.Format("2006-01-02 Mon 15:04")))
+	}
+	if item.Scheduled != nil {
+		planning = append(planning, fmt.Sprintf("SCHEDULED: <%s>", parser.FormatOrgDate(*item.Scheduled)))
+	}
+	if item.Deadline != nil {
+		planning = append(planning, fmt.Sprintf("DEADLINE: <%s>", parser.FormatOrgDate(*item.Deadline)))
+	}
+	if len(planning) > 0 {
+		sections = append(sections, []string{strings.Join(planning, " ")})
+	}
+
+	if notes := markdownNotes(item.Notes); len(notes) > 0 {
+		sections = append(sections, notes)
+	}
+	for _, child := range item.Children {
+		sections = appendMarkdownItem(sections, child)
+	}
+	return sections
+}
+
+// markdownHeading converts an item's heading line
+func markdownHeading(item *model.Item) string {
+	// Markdown has six heading levels
+	level := item.Level
+	if level > 6 {
+		level = 6
+	}
+
+	parts := []string{strings.Repeat("#", level)}
+	if item.State != model.StateNone {
+		parts = append(parts, "**"+string(item.State)+"**")
+	}
+	if item.Priority != model.PriorityNone {
+		parts = append(parts, "[#"+string(item.Priority)+"]")
+	}
+	parts = append(parts, markdownInline(item.Title))
+	if len(item.Tags) > 0 {
+		parts = append(parts, "`:"+strings.Join(item.Tags, ":")+":`")
+	}
+	return strings.Join(parts, " ")
+}
+
+// markdownNotes converts the notes under a heading
+func markdownNotes(notes []string) []string {
+	lines := model.Dedent(notes)
+	tables := make(map[int]*model.Table)
+	for _, table := range model.FindTables(lines) {
+		tables[table.Start] = table
+	}
+
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if table, ok := tables[i]; ok {
+			out = appendSeparated(out, markdownTable(table))
+			i = table.End - 1
+			continue
+		}
+
+		// Markdown code fences are already Markdown
+		if markdownFencePrefix.MatchString(line) {
+			fence := []string{line}
+			for i++; i < len(lines); i++ {
+				fence = append(fence, lines[i])
+				if markdownFencePrefix.MatchString(lines[i]) {
+					break
+				}
+			}
+			out = appendSeparated(out, fence)
+			continue
+		}
+
+		if name, ok := model.BlockBegin(line); ok && model.BlockEndIndex(lines, i, name) >= 0 {
+			end := model.BlockEndIndex(lines, i, name)
+			out = appendSeparated(out, markdownBlock(name, line, lines[i+1:end]))
+			i = end
+			continue
+		}
+
+		switch {
+		case orgDrawerStart.MatchString(line):
+			for i < len(lines) && !orgDrawerEnd.MatchString(lines[i]) {
+				i++
+			}
+		case orgFixedWidth.MatchString(line):
+			fixed := []string{"```"}
+			for ; i < len(lines) && orgFixedWidth.MatchString(lines[i]); i++ {
+				fixed = append(fixed, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ":"), " "))
+			}
+			i--
+			out = appendSeparated(out, append(fixed, "```"))
+		case isPlanningLine(line), orgKeywordPattern.MatchString(line), orgCommentPattern.MatchString(line):
+		case orgRulePattern.MatchString(line):
+			out = append(out, "---")
+		default:
+			out = append(out, markdownListItem(line))
+		}
+	}
+	return trimBlankLines(out)
+}
+
+// appendSeparated adds lines set off from the text around them by blank lines,
+// which Markdown needs around tables, fences and quotes
+func appendSeparated(out, lines []string) []string {
+	if len(out) > 0 && out[len(out)-1] != "" {
+		out = append(out, "")
+	}
+	return append(append(out, lines...), "")
+}
+
+// markdownBlock converts an org block with the given content
+func markdownBlock(name, begin string, content []string) []string {
+	fields := strings.Fields(begin)
+	switch name {
+	case "SRC":
+		language := ""
+		if len(fields) > 1 {
+			language = strings.ToLower(fields[1])
+		}
+		return append(append([]string{"```" + language}, model.Dedent(content)...), "```")
+	case "QUOTE", "VERSE", "CENTER":
+		var quoted []string
+		for _, line := range markdownNotes(content) {
+			quoted = append(quoted, strings.TrimRight("> "+line, " "))
+		}
+		return quoted
+	case "COMMENT":
+		return nil
+	case "EXPORT":
+		// Only text meant for Markdown or HTML can be kept as it is
+		if len(fields) > 1 {
+			switch strings.ToLower(fields[1]) {
+			case "md", "markdown", "html":
+				return model.Dedent(content)
+			}
+		}
+		return nil
+	case model.DynamicBlock:
+		return markdownNotes(content)
+	default:
+		return append(append([]string{"```"}, model.Dedent(content)...), "```")
+	}
+}
+
+// markdownListItem converts a list item, or any other line of text
+func markdownListItem(line string) string {
+	parts := orgListItemPattern.FindStringSubmatch(line)
+	// A * bullet at the start of the line would be a heading in org
+	if parts == nil || (parts[2] == "*" && parts[1] == "") {
+		return markdownInline(line)
+	}
+
+	indent, bullet, checkbox, text := parts[1], parts[2], parts[3], parts[4]
+	if strings.ContainsAny(bullet, "0123456789") {
+		bullet = strings.TrimRight(bullet, ".)") + "."
+	} else {
+		bullet = "-"
+		if desc := orgDescPattern.FindStringSubmatch(text); desc != nil && checkbox == "" {
+			return indent + "- **" + markdownInline(desc[1]) + "**: " + markdownInline(desc[2])
+		}
+	}
+
+	switch checkbox {
+	case "":
+	case "x", "X":
+		bullet += " [x]"
+	default:
+		bullet += " [ ]"
+	}
+	return indent + bullet + " " + markdownInline(text)
+}
+
+// markdownTable converts an org table. Markdown tables need a header row, so an
+// empty one is added if the table has none.
+func markdownTable(table *model.Table) []string {
+	width := table.Width()
+	row := func(cells []string) string {
+		converted := make([]string, width)
+		for i := range converted {
+			if i < len(cells) {
+				converted[i] = markdownInline(cells[i])
+			}
+		}
+		return "| " + strings.Join(converted, " | ") + " |"
+	}
+
+	header, rows := tableRows(table)
+
+	delimiters := make([]string, width)
+	for i := range delimiters {
+		delimiters[i] = "---"
+		if table.IsNumericColumn(i) {
+			delimiters[i] = "---:"
+		}
+	}
+
+	lines := []string{row(header), "|" + strings.Join(delimiters, "|") + "|"}
+	for _, cells := range rows {
+		lines = append(lines, row(cells))
+	}
+	return lines
+}
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	mdTaskPattern      = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+	mdListPattern      = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdFencePattern     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([^`\\s]*)")
+	mdQuotePattern     = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdRulePattern      = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdDelimiterPattern = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)*\s*:?-+:?\s*\|?\s*$`)
+	mdTagsPattern      = regexp.MustCompile("\\s+`:([[:alnum:]_@#%:]+):`$")
+	mdStatePattern     = regexp.MustCompile(`^(?:\*\*(\S+)\*\*|(\S+))\s+`)
+	mdPriorityPattern  = regexp.MustCompile(`^\[#([A-C])\]\s+`)
+
+	mdCodeSpanPattern = regexp.MustCompile("``(.+?)``|`([^`]+)`")
+	mdEscapePattern   = regexp.MustCompile("\\\\[!-/:-@\\[-`{-~]")
+
+	mdImagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+	mdLinkPattern     = regexp.MustCompile(`\[([^\]]+)\]\((<[^>]+>|[^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdAutolinkPattern = regexp.MustCompile(`<((?:https?|ftp|mailto):[^>\s]+)>`)
+	mdBoldPattern     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	mdStrikePattern   = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	mdItalicPattern   = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*|\b_(\S(?:.*?\S)?)_\b`)
+	mdInsPattern      = regexp.MustCompile(`<ins>(.*?)</ins>`)
+
+	mdEscapeOrCodePattern = regexp.MustCompile(mdEscapePattern.String() + "|" + mdCodeSpanPattern.String())
+)
+
+// orgInline converts Markdown links and inline markup in a line to org
+func orgInline(text string) string {
+	c := &inlineConverter{}
+	return c.restore(c.org(text))
+}
+
+// org converts Markdown links and inline markup in a line to org, leaving the
+// converted parts held
+func (c *inlineConverter) org(text string) string {
+	firstGroup := func(parts []string) string {
+		if parts[1] != "" {
+			return parts[1]
+		}
+		return parts[2]
+	}
+
+	// An escaped backtick doesn't open a code span, and a backslash in a code span
+	// doesn't escape, so both are read in one pass
+	text = mdEscapeOrCodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if strings.HasPrefix(match, "\\") {
+			return c.hold(match[1:])
+		}
+		return c.hold("~" + strings.TrimSpace(firstGroup(mdCodeSpanPattern.FindStringSubmatch(match))) + "~")
+	})
+	text = mdImagePattern.ReplaceAllStringFunc(text, func(image string) string {
+		return c.hold("[[" + mdImagePattern.FindStringSubmatch(image)[2] + "]]")
+	})
+	text = mdLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		parts := mdLinkPattern.FindStringSubmatch(link)
+		return c.hold("[[" + strings.Trim(parts[2], "<>") + "][" + c.org(parts[1]) + "]]")
+	})
+	text = mdAutolinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		return c.hold("[[" + mdAutolinkPattern.FindStringSubmatch(link)[1] + "]]")
+	})
+
+	for _, emphasis := range []struct {
+		pattern *regexp.Regexp
+		marker  string
+	}{
+		{mdBoldPattern, "*"},
+		{mdStrikePattern, "+"},
+		{mdItalicPattern, "/"},
+		{mdInsPattern, "_"},
+	} {
+		text = emphasis.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			parts := emphasis.pattern.FindStringSubmatch(match)
+			inner := parts[1]
+			if len(parts) > 2 && inner == "" {
+				inner = parts[2]
+			}
+			return c.hold(emphasis.marker + c.org(inner) + emphasis.marker)
+		})
+	}
+	return text
+}
+
+// orgEntry is an org heading with the notes below it
+type orgEntry struct {
+	heading string
+	notes   []string
+}
+
+// taskItem is the position of a task list item in its Markdown line
+type taskItem struct {
+	indent  int // Indentation of the bullet
+	content int // Indentation of text under the item
+}
+
+// markdownImporter builds org entries from Markdown one line at a time
+type markdownImporter struct {
+	cfg          *config.Config
+	entries      []*orgEntry // The first entry holds the text before any heading
+	heading      *orgEntry   // Entry of the last Markdown heading
+	headingLevel int         // Level of the last Markdown heading
+	tasks        []taskItem  // The enclosing task list items
+	fence        string      // Marker of the open code fence
+	fenceBlock   string      // Name of the org block the fence became
+	inQuote      bool
+}
+
+// ImportMarkdown converts Markdown to org text. Headings become org headings, and
+// task list items become TODO or DONE headings below the current heading, nested
+// by their indentation. Text indented under a task item becomes its notes, and
+// any other text becomes notes of the heading above it.
+func ImportMarkdown(r io.Reader, w io.Writer, cfg *config.Config) error {
+	preamble := &orgEntry{}
+	importer := &markdownImporter{cfg: cfg, entries: []*orgEntry{preamble}, heading: preamble}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		importer.addLine(strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	importer.closeBlocks()
+
+	writer := bufio.NewWriter(w)
+	for _, entry := range importer.entries {
+		lines := trimBlankLines(entry.notes)
+		if entry.heading != "" {
+			lines = append([]string{entry.heading}, lines...)
+		}
+		for _, line := range lines {
+			if _, err := writer.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// target returns the entry that text belongs to: the innermost task list item,
+// or the heading if the text is not inside a task list
+func (im *markdownImporter) target() *orgEntry {
+	if len(im.tasks) > 0 {
+		return im.entries[len(im.entries)-1]
+	}
+	return im.heading
+}
+
+func (im *markdownImporter) addNote(line string) {
+	entry := im.target()
+	entry.notes = append(entry.notes, line)
+}
+
+// leaveTasks ends the task list items that a line is not indented under
+func (im *markdownImporter) leaveTasks(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	for len(im.tasks) > 0 && indent < im.tasks[len(im.tasks)-1].content {
+		im.tasks = im.tasks[:len(im.tasks)-1]
+	}
+}
+
+// addEntry starts a new org heading
+func (im *markdownImporter) addEntry(heading string) *orgEntry {
+	im.closeBlocks()
+	entry := &orgEntry{heading: heading}
+	im.entries = append(im.entries, entry)
+	return entry
+}
+
+func (im *markdownImporter) addLine(line string) {
+	if im.fence != "" {
+		if strings.HasPrefix(strings.TrimSpace(line), im.fence) {
+			im.fence = ""
+			im.addNote("#+END_" + im.fenceBlock)
+		} else {
+			im.addNote(im.stripTaskIndent(line))
+		}
+		return
+	}
+
+	quote := mdQuotePattern.FindStringSubmatch(line)
+	if im.inQuote && quote == nil {
+		im.addNote("#+END_QUOTE")
+		im.inQuote = false
+	}
+
+	if parts := mdHeadingPattern.FindStringSubmatch(line); parts != nil {
+		im.headingLevel = len(parts[1])
+		im.tasks = nil
+		im.heading = im.addEntry(im.orgHeading(im.headingLevel, "", parts[2]))
+		return
+	}
+
+	if parts := mdTaskPattern.FindStringSubmatch(line); parts != nil {
+		indent := len(parts[1])
+		for len(im.tasks) > 0 && im.tasks[len(im.tasks)-1].indent >= indent {
+			im.tasks = im.tasks[:len(im.tasks)-1]
+		}
+		state := im.cfg.GetDefaultNewTaskState()
+		if parts[3] != " " {
+			state = string(model.StateDONE)
+		}
+		im.addEntry(im.orgHeading(im.headingLevel+1+len(im.tasks), state, parts[4]))
+		im.tasks = append(im.tasks, taskItem{indent: indent, content: indent + len(parts[2]) + 1})
+		return
+	}
+
+	if !im.inQuote {
+		im.leaveTasks(line)
+	}
+
+	if parts := mdFencePattern.FindStringSubmatch(line); parts != nil {
+		// Fences without a language hold plain text, like org example blocks
+		im.fence = parts[1]
+		im.fenceBlock = "EXAMPLE"
+		begin := "#+BEGIN_EXAMPLE"
+		if parts[2] != "" {
+			im.fenceBlock = "SRC"
+			begin = "#+BEGIN_SRC " + parts[2]
+		}
+		im.addNote(begin)
+		return
+	}
+
+	if quote != nil {
+		if !im.inQuote {
+			im.addNote("#+BEGIN_QUOTE")
+			im.inQuote = true
+		}
+		im.addNote(orgInline(quote[1]))
+		return
+	}
+
+	line = im.stripTaskIndent(line)
+	switch {
+	case isPlanningLine(line):
+		im.addNote(strings.TrimSpace(line))
+	case mdRulePattern.MatchString(line):
+		im.addNote("-----")
+	case mdDelimiterPattern.MatchString(line) && strings.Contains(line, "|"):
+		columns := strings.Count(strings.Trim(strings.TrimSpace(line), "|"), "|") + 1
+		rule := make([]string, columns)
+		for i := range rule {
+			rule[i] = "---"
+		}
+		im.addNote("|" + strings.Join(rule, "+") + "|")
+	default:
+		if parts := mdListPattern.FindStringSubmatch(line); parts != nil {
+			line = parts[1] + "- " + parts[2]
+		}
+		im.addNote(orgInline(line))
+	}
+}
+
+// stripTaskIndent removes the indentation that places a line under a task list item
+func (im *markdownImporter) stripTaskIndent(line string) string {
+	if len(im.tasks) == 0 {
+		return line
+	}
+	indent := im.tasks[len(im.tasks)-1].content
+	if len(line)-len(strings.TrimLeft(line, " ")) >= indent {
+		return line[indent:]
+	}
+	return strings.TrimLeft(line, " \t")
+}
+
+// closeBlocks ends an open quote or code fence before a new heading
+func (im *markdownImporter) closeBlocks() {
+	if im.inQuote {
+		im.addNote("#+END_QUOTE")
+		im.inQuote = false
+	}
+	if im.fence != "" {
+		im.addNote("#+END_" + im.fenceBlock)
+		im.fence = ""
+	}
+}
+
+// orgHeading builds an org heading line from Markdown heading text, reading back
+// the state, priority and tags written by Markdown
+func (im *markdownImporter) orgHeading(level int, state, text string) string {
+	var tags string
+	if parts := mdTagsPattern.FindStringSubmatch(text); parts != nil {
+		tags = ":" + parts[1] + ":"
+		text = text[:len(text)-len(parts[0])]
+	}
+
+	if parts := mdStatePattern.FindStringSubmatch(text); parts != nil {
+		word := parts[1] + parts[2]
+		for _, name := range im.cfg.GetStateNames() {
+			if word == name {
+				state = name
+				text = text[len(parts[0]):]
+				break
+			}
+		}
+	}
+
+	var priority string
+	if parts := mdPriorityPattern.FindStringSubmatch(text); parts != nil {
+		priority = "[#" + parts[1] + "]"
+		text = text[len(parts[0]):]
+	}
+
+	heading := []string{strings.Repeat("*", level)}
+	for _, part := range []string{state, priority, orgInline(text), tags} {
+		if part != "" {
+			heading = append(heading, part)
+		}
+	}
+	return strings.Join(heading, " ")
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rwejlgaard/org/internal/config"
+)
+
+func TestMarkdownInline(t *testing.T) {
+	tests := []struct {
+		org, markdown string
+	}{
+		{"*see [[https://example.com][the docs]] first*", "**see [the docs](https://example.com) first**"},
+		{"=a*b*= and ~code~", "`a*b*` and `code`"},
+		{"snake_case_names and /italic/", "snake_case_names and *italic*"},
+	}
+	for _, tt := range tests {
+		if got := markdownInline(tt.org); got != tt.markdown {
+			t.Errorf("markdownInline(%q) = %q, want %q", tt.org, got, tt.markdown)
+		}
+	}
+}
+
+func TestMarkdownEscapeRoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"2*3*4",
+		"a * b and _x_y",
+		"run `make` in C:\\build",
+		`literal \*stars\*`,
+		"snake_case_names",
+	} {
+		markdown := markdownInline(text)
+		if got := orgInline(markdown); got != text {
+			t.Errorf("%q exported as %q and imported as %q", text, markdown, got)
+		}
+	}
+}
+
+func TestImportOrderedTaskList(t *testing.T) {
+	markdown := "# Plan\n\n1. [ ] Draft\n   notes under it\n2. [x] Review\n"
+	var out strings.Builder
+	if err := ImportMarkdown(strings.NewReader(markdown), &out, config.DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	want := "* Plan\n** TODO Draft\nnotes under it\n** DONE Review\n"
+	if out.String() != want {
+		t.Errorf("imported\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+var (
+	orgLinkPattern     = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	orgListItemPattern = regexp.MustCompile(`^(\s*)([-+*]|\d+[.)])\s+(?:\[([ xX-])\]\s+)?(.*)$`)
+	orgDescPattern     = regexp.MustCompile(`^(.*?)\s+::(?:\s+(.*))?$`)
+	orgFixedWidth      = regexp.MustCompile(`^\s*:(\s|$)`)
+	orgDrawerStart     = regexp.MustCompile(`^\s*:[A-Za-z][\w-]*:\s*$`)
+	orgDrawerEnd       = regexp.MustCompile(`(?i)^\s*:END:\s*$`)
+	orgKeywordPattern  = regexp.MustCompile(`^\s*#\+\S*:`)
+	orgCommentPattern  = regexp.MustCompile(`^\s*#(\s|$)`)
+	orgRulePattern     = regexp.MustCompile(`^\s*-{5,}\s*$`)
+)
+
+// heldPattern matches the placeholders left by inlineConverter.hold
+var heldPattern = regexp.MustCompile(string(model.ProtectStart) + "([0-9]+)" + string(model.ProtectEnd))
+
+// inlineConverter keeps converted parts of a line out of reach of later
+// conversions by holding them as protected parts until the line is done
+type inlineConverter struct {
+	held []string
+}
+
+func (c *inlineConverter) hold(text string) string {
+	c.held = append(c.held, text)
+	return fmt.Sprintf("%c%d%c", model.ProtectStart, len(c.held)-1, model.ProtectEnd)
+}
+
+func (c *inlineConverter) restore(text string) string {
+	return heldPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		i, _ := strconv.Atoi(heldPattern.FindStringSubmatch(placeholder)[1])
+		return c.restore(c.held[i])
+	})
+}
+
+// replaceEmphasis replaces org inline markup in the text with held placeholders
+// for the text returned by render
+func (c *inlineConverter) replaceEmphasis(text string, render func(marker rune, inner string) string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	plainStart := 0
+	for i := 0; i < len(runes); i++ {
+		i = model.SkipProtected(runes, i)
+		end := model.FindEmphasis(runes, i)
+		if end < 0 {
+			continue
+		}
+		b.WriteString(string(runes[plainStart:i]))
+		b.WriteString(c.hold(render(runes[i], string(runes[i+1:end]))))
+		i = end
+		plainStart = end + 1
+	}
+	b.WriteString(string(runes[plainStart:]))
+	return b.String()
+}
+
+// tableRows returns the cells of a table's data rows. The first row is returned
+// as the header if a rule follows it.
+func tableRows(table *model.Table) (header []string, rows [][]string) {
+	hasHeader := false
+	for _, row := range table.Rows {
+		if row.Rule {
+			hasHeader = hasHeader || len(rows) == 1
+			continue
+		}
+		rows = append(rows, row.Cells)
+	}
+	if hasHeader {
+		return rows[0], rows[1:]
+	}
+	return nil, rows
+}
+
+// isPlanningLine returns true if a line holds SCHEDULED, DEADLINE or CLOSED timestamps
+func isPlanningLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "SCHEDULED:") ||
+		strings.HasPrefix(trimmed, "DEADLINE:") ||
+		strings.HasPrefix(trimmed, "CLOSED:")
+}
+
+// trimBlankLines removes leading and trailing blank lines and collapses runs of them
+func trimBlankLines(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		blank := strings.TrimSpace(line) == ""
+		if blank && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		if blank {
+			line = ""
+		}
+		out = append(out, line)
+	}
+	if len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DynamicBlock is the block name reported for dynamic blocks (#+BEGIN: name ... #+END:)
+const DynamicBlock = ":"
+
+var (
+	blockBeginPattern = regexp.MustCompile(`(?i)^\s*#\+BEGIN(_\S+|:)`)
+	blockEndPattern   = regexp.MustCompile(`(?i)^\s*#\+END(_\S+|:)`)
+)
+
+// blockName normalizes the part of a block delimiter after #+BEGIN or #+END
+func blockName(suffix string) string {
+	return strings.ToUpper(strings.TrimPrefix(suffix, "_"))
+}
+
+// BlockBegin returns the upper-cased name of the block the line opens, such as
+// "SRC", "QUOTE" or "EXAMPLE", or DynamicBlock. ok is false if the line does not
+// open a block.
+func BlockBegin(line string) (name string, ok bool) {
+	matches := blockBeginPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+	return blockName(matches[1]), true
+}
+
+// BlockEnd returns the upper-cased name of the block the line closes. ok is false
+// if the line does not close a block.
+func BlockEnd(line string) (name string, ok bool) {
+	matches := blockEndPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+	return blockName(matches[1]), true
+}
+
+// IsBlockDelimiter returns true if the line opens or closes a block
+func IsBlockDelimiter(line string) bool {
+	return blockBeginPattern.MatchString(line) || blockEndPattern.MatchString(line)
+}
+
+// HasBlockEnd returns true if one of the lines closes the named block. Like org,
+// a #+BEGIN line without a matching #+END is not treated as a block.
+func HasBlockEnd(lines []string, name string) bool {
+	for _, line := range lines {
+		if end, ok := BlockEnd(line); ok && end == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SrcBlock is a #+BEGIN_SRC block in an item's notes
+type SrcBlock struct {
+	Start    int      // Index of the #+BEGIN_SRC line
+	End      int      // Index of the #+END_SRC line
+	Language string   // Language from the #+BEGIN_SRC line, lower-cased
+	Code     []string // Lines between the delimiters
+}
+
+// FindSrcBlocks returns the source blocks in the notes, in order
+func FindSrcBlocks(notes []string) []SrcBlock {
+	var blocks []SrcBlock
+	for i := 0; i < len(notes); i++ {
+		name, ok := BlockBegin(notes[i])
+		if !ok {
+			continue
+		}
+		end := BlockEndIndex(notes, i, name)
+		if end < 0 {
+			continue
+		}
+		if name == "SRC" {
+			block := SrcBlock{Start: i, End: end, Code: notes[i+1 : end]}
+			if fields := strings.Fields(notes[i]); len(fields) > 1 {
+				block.Language = strings.ToLower(fields[1])
+			}
+			blocks = append(blocks, block)
+		}
+		// Blocks inside other blocks are part of their content
+		i = end
+	}
+	return blocks
+}
+
+// Dedent removes the indentation common to all non-blank lines, as org does
+// before running or exporting a block
+func Dedent(lines []string) []string {
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+	if common <= 0 {
+		return lines
+	}
+
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= common {
+			dedented[i] = line[common:]
+		} else {
+			// Blank lines may be shorter than the common indentation
+			dedented[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return dedented
+}
+
+// BlockEndIndex returns the index of the line closing the named block opened at
+// start, or -1 if it is not closed
+func BlockEndIndex(lines []string, start int, name string) int {
+	for i := start + 1; i < len(lines); i++ {
+		if end, ok := BlockEnd(lines[i]); ok && end == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// resultsKeyword matches the #+RESULTS: line above a source block's output,
+// including org's #+RESULTS[hash]: form
+var resultsKeyword = regexp.MustCompile(`(?i)^\s*#\+RESULTS(\[[^\]]*\])?:`)
+
+// minLinesForResultsBlock is the number of output lines from which results are
+// wrapped in an example block instead of prefixed with ": ", as in org
+const minLinesForResultsBlock = 10
+
+// SetSrcBlockResults returns the notes with the block's output inserted as a
+// #+RESULTS: section after it, replacing any previous results
+func SetSrcBlockResults(notes []string, block SrcBlock, output string) []string {
+	begin := notes[block.Start]
+	indent := begin[:len(begin)-len(strings.TrimLeft(begin, " \t"))]
+
+	results := []string{indent + "#+RESULTS:"}
+	var lines []string
+	if output = strings.TrimRight(output, "\n"); output != "" {
+		lines = strings.Split(output, "\n")
+	}
+	if len(lines) >= minLinesForResultsBlock {
+		results = append(results, indent+"#+begin_example")
+		for _, line := range lines {
+			results = append(results, strings.TrimRight(indent+line, " "))
+		}
+		results = append(results, indent+"#+end_example")
+	} else {
+		for _, line := range lines {
+			results = append(results, strings.TrimRight(indent+": "+line, " "))
+		}
+	}
+
+	// Previous results follow the block, optionally after blank lines
+	resume := block.End + 1
+	next := resume
+	for next < len(notes) && strings.TrimSpace(notes[next]) == "" {
+		next++
+	}
+	if next < len(notes) && resultsKeyword.MatchString(notes[next]) {
+		resume = resultsEnd(notes, next)
+	}
+
+	updated := append([]string{}, notes[:block.End+1]...)
+	updated = append(updated, "")
+	updated = append(updated, results...)
+	return append(updated, notes[resume:]...)
+}
+
+// resultsEnd returns the index after the results that follow the #+RESULTS: line
+// at the given index: ": " lines, a table, or a block
+func resultsEnd(notes []string, keyword int) int {
+	i := keyword + 1
+	if i < len(notes) {
+		if name, ok := BlockBegin(notes[i]); ok {
+			if end := BlockEndIndex(notes, i, name); end >= 0 {
+				return end + 1
+			}
+		}
+	}
+	for i < len(notes) {
+		trimmed := strings.TrimSpace(notes[i])
+		if trimmed != ":" && !strings.HasPrefix(trimmed, ": ") && !IsTableLine(notes[i]) {
+			break
+		}
+		i++
+	}
+	return i
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "mixed indentation",
+			lines: []string{"def f():", "    return 1", "", "print(f())"},
+			want:  []string{"def f():", "    return 1", "", "print(f())"},
+		},
+		{
+			name:  "common indentation",
+			lines: []string{"  def f():", "      return 1", " ", "  print(f())"},
+			want:  []string{"def f():", "    return 1", "", "print(f())"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Dedent(tt.lines); !slices.Equal(got, tt.want) {
+				t.Errorf("Dedent(%q) = %q, want %q", tt.lines, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,3 +7,10 @@ type ClockEntry struct {
 	Start time.Time
 	End   *time.Time // nil if currently clocked in
 }
+
+// LogbookNote is a line of a :LOGBOOK: drawer that isn't a clock entry, such as
+// a state change or a note
+type LogbookNote struct {
+	Line   string
+	Clocks int // Number of clock entries before the line in the drawer
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EffortUnits defines the working-time length of day and week effort units
+type EffortUnits struct {
+	HoursPerDay float64
+	DaysPerWeek float64
+}
+
+// DefaultEffortUnits are used when no configuration is available (8h days, 5d weeks)
+var DefaultEffortUnits = EffortUnits{HoursPerDay: 8, DaysPerWeek: 5}
+
+var (
+	effortClockPattern = regexp.MustCompile(`^(\d+):(\d{2})$`)
+	effortUnitPattern  = regexp.MustCompile(`^(\d+(?:\.\d+)?)(min|w|d|h|m)$`)
+	effortSplitPattern = regexp.MustCompile(`(\d+(?:\.\d+)?(?:min|w|d|h|m)|\d+:\d{2}|\S+)`)
+)
+
+// ParseEffort parses an effort estimate into a duration. It accepts org's
+// H:MM format ("1:30"), unit suffixes ("8h", "2d", "1w", "30min", "4h30m"),
+// combinations of both ("1d 2:30") and a bare number of minutes.
+// Days and weeks are converted using the given working-time units.
+func ParseEffort(effort string, units EffortUnits) (time.Duration, bool) {
+	effort = strings.ToLower(strings.TrimSpace(effort))
+	if effort == "" {
+		return 0, false
+	}
+
+	// A bare number is a number of minutes, as in org-duration
+	if minutes, err := strconv.ParseFloat(effort, 64); err == nil {
+		return time.Duration(minutes * float64(time.Minute)), true
+	}
+
+	var total time.Duration
+	for _, part := range effortSplitPattern.FindAllString(effort, -1) {
+		if matches := effortClockPattern.FindStringSubmatch(part); matches != nil {
+			hours, _ := strconv.Atoi(matches[1])
+			minutes, _ := strconv.Atoi(matches[2])
+			total += time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+			continue
+		}
+
+		matches := effortUnitPattern.FindStringSubmatch(part)
+		if matches == nil {
+			return 0, false
+		}
+		value, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		var unit float64
+		switch matches[2] {
+		case "w":
+			unit = units.DaysPerWeek * units.HoursPerDay * float64(time.Hour)
+		case "d":
+			unit = units.HoursPerDay * float64(time.Hour)
+		case "h":
+			unit = float64(time.Hour)
+		case "m", "min":
+			unit = float64(time.Minute)
+		}
+		total += time.Duration(value * unit)
+	}
+	return total, true
+}
+
+// GetEffortRollup returns the estimated effort of an item's subtree.
+// If any descendants have estimates, their sum is used (as org column view
+// summaries do); otherwise the item's own estimate is returned.
+// ok is false if neither the item nor its descendants have a valid estimate.
+func (item *Item) GetEffortRollup(units EffortUnits) (time.Duration, bool) {
+	var childTotal time.Duration
+	hasChildEffort := false
+	for _, child := range item.Children {
+		if effort, ok := child.GetEffortRollup(units); ok {
+			childTotal += effort
+			hasChildEffort = true
+		}
+	}
+	if hasChildEffort {
+		return childTotal, true
+	}
+	return ParseEffort(item.GetEffort(), units)
+}
+
+// GetSubtreeClockDuration returns the total clocked time of an item and all its descendants
+func (item *Item) GetSubtreeClockDuration() time.Duration {
+	total := item.GetTotalClockDuration()
+	for _, child := range item.Children {
+		total += child.GetSubtreeClockDuration()
+	}
+	return total
+}
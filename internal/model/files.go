@@ -0,0 +1,36 @@
+package model
+
+// WrapperKind tells the items multi-file mode adds for files and directories
+// apart from the headings read from the files
+type WrapperKind int
+
+const (
+	NotWrapper  WrapperKind = iota // A heading from a file
+	FileWrapper                    // A file, with its preamble as notes and its headings as children
+	DirWrapper                     // A directory, with its files and subdirectories as children
+)
+
+// IsWrapper returns true if the item stands for a file or directory
+func (item *Item) IsWrapper() bool {
+	return item.Wrapper != NotWrapper
+}
+
+// IsMultiFile returns true if the items are the files of a directory
+func (of *OrgFile) IsMultiFile() bool {
+	return len(of.Items) > 0 && of.Items[0].IsWrapper()
+}
+
+// FileItems returns the file items among the given items and inside their
+// directory items, in order
+func FileItems(list []*Item) []*Item {
+	var files []*Item
+	for _, item := range list {
+		switch item.Wrapper {
+		case FileWrapper:
+			files = append(files, item)
+		case DirWrapper:
+			files = append(files, FileItems(item.Children)...)
+		}
+	}
+	return files
+}
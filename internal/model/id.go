@@ -0,0 +1,75 @@
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// NewID generates a random (version 4) UUID for use as an :ID: property
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate ID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// EnsureID returns the item's :ID: property, generating one if it has none.
+// created is true if a new ID was added.
+func (item *Item) EnsureID() (id string, created bool) {
+	if id := item.GetProperty("ID"); id != "" {
+		return id, false
+	}
+	id = NewID()
+	item.SetProperty("ID", id)
+	return id, true
+}
+
+// BuildIDIndex rebuilds the index of items by their :ID: property.
+// In multi-file mode the index covers every loaded file. If an ID is
+// duplicated, the first item in document order wins.
+func (of *OrgFile) BuildIDIndex() {
+	of.idIndex = make(map[string]*Item)
+	for _, item := range FlattenAllItems(of.Items) {
+		if id := item.GetProperty("ID"); id != "" {
+			if _, exists := of.idIndex[id]; !exists {
+				of.idIndex[id] = item
+			}
+		}
+	}
+}
+
+// FindByID returns the item with the given :ID: property, or nil.
+// The index is rebuilt when it is missing the ID or holds a stale entry.
+func (of *OrgFile) FindByID(id string) *Item {
+	if of.idIndex != nil {
+		if item := of.idIndex[id]; item != nil && item.GetProperty("ID") == id {
+			return item
+		}
+	}
+	of.BuildIDIndex()
+	return of.idIndex[id]
+}
+
+// GetBlockerIDs returns the IDs listed in the item's :BLOCKER: property.
+// IDs may be separated by spaces or commas, prefixed with "id:", or wrapped
+// in org-edna's ids(...) form.
+func (item *Item) GetBlockerIDs() []string {
+	value := item.GetProperty("BLOCKER")
+	if value == "" {
+		return nil
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "ids("), ")")
+
+	var ids []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+		field = strings.Trim(strings.TrimPrefix(field, "id:"), "\"")
+		if field != "" {
+			ids = append(ids, field)
+		}
+	}
+	return ids
+}
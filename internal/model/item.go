@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+)
 
 // Priority represents org-mode priority levels
 type Priority string
@@ -21,19 +25,49 @@ type Item struct {
 	Tags         []string     // Tags for this item (e.g., :work:urgent:)
 	Scheduled    *time.Time
 	Deadline     *time.Time
-	Closed       *time.Time   // Closed timestamp (when task was marked as done)
-	Effort       string       // Effort estimate (e.g., "8h", "2d")
-	Notes        []string     // Notes/content under the heading
-	Children     []*Item      // Sub-items
-	Folded       bool         // Whether the item is folded (hides notes and children)
-	ClockEntries []ClockEntry // Clock in/out entries
-	SourceFile   string       // Source file path (used in multi-file mode)
+	Closed       *time.Time    // Closed timestamp (when task was marked as done)
+	Notes        []string      // Body text under the heading, after its planning line and drawers
+	Children     []*Item       // Sub-items
+	Folded       bool          // Whether the item is folded (hides notes and children)
+	ClockEntries []ClockEntry  // Clock in/out entries, newest first as in the :LOGBOOK: drawer
+	LogbookNotes []LogbookNote // Lines of the :LOGBOOK: drawer other than clock entries
+	Properties   []Property    // Entries of the :PROPERTIES: drawer, in order
+	SourceFile   string        // Source file path (used in multi-file mode; the directory path for directory items)
+	Wrapper      WrapperKind   // Whether the item stands for a file or directory in multi-file mode
+	RawHeading   RawHeading    // Heading line as read from the file
+	RawMetadata  RawMetadata   // Planning line and drawers as read from the file
+}
+
+// RawHeading is a heading line as it was read from a file. While the heading's
+// fields are unchanged it is written back instead of a line formatted from them,
+// so spacing, tag alignment and syntax the parser doesn't model are kept.
+type RawHeading struct {
+	Line      string // The line after its stars
+	Formatted string // The line after its stars as formatted from the fields it was parsed into
+}
+
+// RawMetadata is the planning line and drawers below a heading as they were read
+// from a file. They are written back as they were while the fields they were
+// parsed into format to the same lines.
+type RawMetadata struct {
+	Lines     []string // The lines as read
+	Formatted []string // The lines as formatted from the fields they were parsed into
+}
+
+// Property is an entry of an item's :PROPERTIES: drawer
+type Property struct {
+	Name  string
+	Value string
 }
 
 // OrgFile represents a parsed org-mode file
 type OrgFile struct {
-	Path  string
-	Items []*Item
+	Path     string
+	Items    []*Item
+	Preamble []string            // Lines before the first heading (e.g. #+TITLE, #+COLUMNS)
+	Saved    map[string][32]byte // SHA-256 of each file's text as last read or written, so unchanged files aren't written
+
+	idIndex map[string]*Item // Items by :ID: property, see BuildIDIndex
 }
 
 // ToggleFold toggles the folded state of an item
@@ -57,7 +91,7 @@ func (item *Item) CycleState() {
 	}
 }
 
-// ClockIn starts a new clock entry
+// ClockIn starts a new clock entry at the top of the logbook, as org does
 func (item *Item) ClockIn() bool {
 	// Check if already clocked in
 	if item.IsClockedIn() {
@@ -68,23 +102,63 @@ func (item *Item) ClockIn() bool {
 		Start: time.Now(),
 		End:   nil,
 	}
-	item.ClockEntries = append(item.ClockEntries, entry)
+	item.ClockEntries = append([]ClockEntry{entry}, item.ClockEntries...)
+	// The other lines of the logbook stay below the new entry
+	for i := range item.LogbookNotes {
+		item.LogbookNotes[i].Clocks++
+	}
 	return true
 }
 
+// AddLogbookNote adds a line at the top of the :LOGBOOK: drawer, above the
+// newest clock entry
+func (item *Item) AddLogbookNote(line string) {
+	item.LogbookNotes = append([]LogbookNote{{Line: line}}, item.LogbookNotes...)
+}
+
 // ClockOut ends the current clock entry
 func (item *Item) ClockOut() bool {
-	// Find the most recent open clock entry
-	for i := len(item.ClockEntries) - 1; i >= 0; i-- {
-		if item.ClockEntries[i].End == nil {
-			now := time.Now()
-			item.ClockEntries[i].End = &now
-			return true
+	return item.ClockOutAt(time.Now())
+}
+
+// ClockOutAt ends the current clock entry at the given time
+func (item *Item) ClockOutAt(end time.Time) bool {
+	entry := item.OpenClockEntry()
+	if entry == nil {
+		return false
+	}
+	entry.End = &end
+	return true
+}
+
+// CancelClock removes the current clock entry without recording any time
+func (item *Item) CancelClock() bool {
+	for i, entry := range item.ClockEntries {
+		if entry.End != nil {
+			continue
+		}
+		item.ClockEntries = append(item.ClockEntries[:i], item.ClockEntries[i+1:]...)
+		for j := range item.LogbookNotes {
+			if item.LogbookNotes[j].Clocks > i {
+				item.LogbookNotes[j].Clocks--
+			}
 		}
+		return true
 	}
 	return false
 }
 
+// OpenClockEntry returns the most recent open clock entry, or nil if the item is
+// not clocked in
+func (item *Item) OpenClockEntry() *ClockEntry {
+	for i := range item.ClockEntries {
+		if item.ClockEntries[i].End == nil {
+			return &item.ClockEntries[i]
+		}
+	}
+	return nil
+}
+
 // IsClockedIn returns true if there's an active clock entry
 func (item *Item) IsClockedIn() bool {
 	for _, entry := range item.ClockEntries {
@@ -120,9 +194,83 @@ func (item *Item) GetTotalClockDuration() time.Duration {
 	return total
 }
 
+// GetProperty returns the value of a property in the item's :PROPERTIES: drawer
+// Property names are matched case-insensitively; returns "" if not set
+func (item *Item) GetProperty(name string) string {
+	for _, property := range item.Properties {
+		if strings.EqualFold(property.Name, name) {
+			return property.Value
+		}
+	}
+	return ""
+}
+
+// SetProperty sets a property in the item's :PROPERTIES: drawer
+// An empty value removes the property
+func (item *Item) SetProperty(name, value string) {
+	for i, property := range item.Properties {
+		if !strings.EqualFold(property.Name, name) {
+			continue
+		}
+		if value == "" {
+			item.Properties = append(item.Properties[:i], item.Properties[i+1:]...)
+		} else {
+			// Keep the existing spelling of the property name (e.g. :Effort:)
+			item.Properties[i].Value = value
+		}
+		return
+	}
+
+	if value != "" {
+		item.Properties = append(item.Properties, Property{Name: name, Value: value})
+	}
+}
+
+// legacyEffortPattern matches an :EFFORT: line outside a :PROPERTIES: drawer, as
+// older versions read from anywhere in an item's notes
+var legacyEffortPattern = regexp.MustCompile(`^\s*:EFFORT:\s*(\S.*?)\s*$`)
+
+// GetEffort returns the item's effort estimate (e.g., "8h", "2d") from its :EFFORT:
+// property, or else from an :EFFORT: line in its notes
+func (item *Item) GetEffort() string {
+	if effort := item.GetProperty("EFFORT"); effort != "" {
+		return effort
+	}
+	if i := item.legacyEffortIndex(); i >= 0 {
+		return legacyEffortPattern.FindStringSubmatch(item.Notes[i])[1]
+	}
+	return ""
+}
+
+// SetEffort sets the item's :EFFORT: property, moving an :EFFORT: line in its
+// notes into the :PROPERTIES: drawer. An empty effort removes both.
+func (item *Item) SetEffort(effort string) {
+	if i := item.legacyEffortIndex(); i >= 0 {
+		item.Notes = append(item.Notes[:i:i], item.Notes[i+1:]...)
+	}
+	item.SetProperty("EFFORT", effort)
+}
+
+// legacyEffortIndex returns the index of the first :EFFORT: line in the item's
+// notes, or -1
+func (item *Item) legacyEffortIndex() int {
+	for i, line := range item.Notes {
+		if legacyEffortPattern.MatchString(line) {
+			return i
+		}
+	}
+	return -1
+}
+
 // GetAllItems returns a flattened list of all items (for UI display)
 // Respects folding - folded items don't show their children
 func (of *OrgFile) GetAllItems() []*Item {
+	return FlattenItems(of.Items)
+}
+
+// FlattenItems returns a flattened list of the given items and their descendants
+// Respects folding - folded items don't show their children
+func FlattenItems(list []*Item) []*Item {
 	var items []*Item
 	var flatten func([]*Item)
 	flatten = func(list []*Item) {
@@ -133,6 +281,17 @@ func (of *OrgFile) GetAllItems() []*Item {
 			}
 		}
 	}
-	flatten(of.Items)
+	flatten(list)
+	return items
+}
+
+// FlattenAllItems returns a flattened list of the given items and all their descendants,
+// including the children of folded items
+func FlattenAllItems(list []*Item) []*Item {
+	var items []*Item
+	for _, item := range list {
+		items = append(items, item)
+		items = append(items, FlattenAllItems(item.Children)...)
+	}
 	return items
 }
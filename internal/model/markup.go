@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// EmphasisMarkers are the characters that delimit org inline markup:
+// *bold*, /italic/, _underline_, =verbatim=, ~code~ and +strike-through+
+const EmphasisMarkers = "*/_=~+"
+
+// Parts of a line that inline markup may not reach into, such as links, can be
+// held between ProtectStart and ProtectEnd while the markup is found, and spaces
+// that should not break the line encoded as ProtectSpace. The private-use
+// characters don't occur in org text.
+const (
+	ProtectSpace = '\uE000'
+	ProtectStart = '\uE001'
+	ProtectEnd   = '\uE003'
+)
+
+// isEmphasisPre returns true if r may come before an opening emphasis marker
+func isEmphasisPre(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`-('"{`, r) ||
+		r == ProtectSpace || r == ProtectEnd
+}
+
+// isEmphasisPost returns true if r may come after a closing emphasis marker
+func isEmphasisPost(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`-.,:!?;'")}[`, r) ||
+		r == ProtectSpace || r == ProtectStart
+}
+
+// isEmphasisBorder returns true if emphasized text may not begin or end with r
+func isEmphasisBorder(r rune) bool {
+	return unicode.IsSpace(r) || r == ProtectSpace
+}
+
+// SkipProtected returns the index of the ProtectEnd closing the protected part
+// starting at runes[i], or i if none starts there
+func SkipProtected(runes []rune, i int) int {
+	if runes[i] != ProtectStart {
+		return i
+	}
+	for j := i + 1; j < len(runes); j++ {
+		if runes[j] == ProtectEnd {
+			return j
+		}
+	}
+	return i
+}
+
+// FindEmphasis returns the index of the marker closing emphasis opened at runes[start],
+// or -1 if there is none. Following org's rules, the opening marker must start the
+// text or follow whitespace or opening punctuation, the emphasized text may not begin
+// or end with whitespace, and the closing marker must end the text or be followed by
+// whitespace or punctuation. This keeps text like 2*3*4 and snake_case_names plain.
+func FindEmphasis(runes []rune, start int) int {
+	marker := runes[start]
+	if !strings.ContainsRune(EmphasisMarkers, marker) {
+		return -1
+	}
+	if start > 0 && !isEmphasisPre(runes[start-1]) {
+		return -1
+	}
+	if start+1 >= len(runes) || isEmphasisBorder(runes[start+1]) {
+		return -1
+	}
+
+	for end := start + 2; end < len(runes); end++ {
+		// Markers inside a protected part belong to it
+		end = SkipProtected(runes, end)
+		if runes[end] != marker || isEmphasisBorder(runes[end-1]) {
+			continue
+		}
+		if end+1 == len(runes) || isEmphasisPost(runes[end+1]) {
+			return end
+		}
+	}
+	return -1
+}
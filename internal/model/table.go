@@ -0,0 +1,464 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Table is an org table found in an item's notes
+type Table struct {
+	Start    int        // Index of the first table line in the notes
+	End      int        // Index after the last table line, including a #+TBLFM line
+	Indent   string     // Leading whitespace of the table lines
+	Rows     []TableRow // Rows in order, including horizontal rules
+	Formulas []string   // Formulas from the #+TBLFM line
+}
+
+// TableRow is a row of cells, or a horizontal rule (|---+---|)
+type TableRow struct {
+	Cells []string
+	Rule  bool
+}
+
+// IsTableLine returns true if the line is part of an org table
+func IsTableLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "|")
+}
+
+// IsTableFormulaLine returns true if the line holds table formulas (#+TBLFM:)
+func IsTableFormulaLine(line string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "#+TBLFM:")
+}
+
+// FindTables returns the tables in the notes, in order. Lines inside blocks
+// and markdown code fences are not treated as tables, except in dynamic blocks
+// whose content is generated org text.
+func FindTables(notes []string) []*Table {
+	var tables []*Table
+	blockName := "" // Name of the open block, "```" for a code fence
+	for i := 0; i < len(notes); i++ {
+		isFence := strings.HasPrefix(strings.TrimSpace(notes[i]), "```")
+		if blockName != "" {
+			if name, ok := BlockEnd(notes[i]); (ok && name == blockName) || (isFence && blockName == "```") {
+				blockName = ""
+			}
+			continue
+		}
+		if name, ok := BlockBegin(notes[i]); ok && name != DynamicBlock && HasBlockEnd(notes[i+1:], name) {
+			blockName = name
+			continue
+		}
+		if isFence {
+			blockName = "```"
+			continue
+		}
+		if !IsTableLine(notes[i]) {
+			continue
+		}
+
+		table := &Table{
+			Start:  i,
+			Indent: notes[i][:len(notes[i])-len(strings.TrimLeft(notes[i], " \t"))],
+		}
+		for ; i < len(notes) && IsTableLine(notes[i]); i++ {
+			table.Rows = append(table.Rows, parseTableRow(notes[i]))
+		}
+		if i < len(notes) && IsTableFormulaLine(notes[i]) {
+			formulas := strings.TrimSpace(strings.TrimSpace(notes[i])[len("#+TBLFM:"):])
+			for _, formula := range strings.Split(formulas, "::") {
+				if formula = strings.TrimSpace(formula); formula != "" {
+					table.Formulas = append(table.Formulas, formula)
+				}
+			}
+			i++
+		}
+		table.End = i
+		table.Normalize()
+		tables = append(tables, table)
+		i-- // The loop increment moves past the table
+	}
+	return tables
+}
+
+// parseTableRow parses a single table line
+func parseTableRow(line string) TableRow {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "|-") {
+		return TableRow{Rule: true}
+	}
+
+	trimmed = strings.TrimPrefix(trimmed, "|")
+	trimmed = strings.TrimSuffix(trimmed, "|")
+	var cells []string
+	for _, cell := range strings.Split(trimmed, "|") {
+		cells = append(cells, strings.TrimSpace(cell))
+	}
+	return TableRow{Cells: cells}
+}
+
+// NewTable creates an empty table with a header row, a rule and one data row
+func NewTable(columns int) *Table {
+	return &Table{
+		Rows: []TableRow{
+			{Cells: make([]string, columns)},
+			{Rule: true},
+			{Cells: make([]string, columns)},
+		},
+	}
+}
+
+// Width returns the number of columns in the table
+func (t *Table) Width() int {
+	width := 0
+	for _, row := range t.Rows {
+		if len(row.Cells) > width {
+			width = len(row.Cells)
+		}
+	}
+	if width == 0 {
+		width = 1
+	}
+	return width
+}
+
+// Normalize pads every data row to the same number of cells
+func (t *Table) Normalize() {
+	width := t.Width()
+	for i := range t.Rows {
+		if t.Rows[i].Rule {
+			continue
+		}
+		for len(t.Rows[i].Cells) < width {
+			t.Rows[i].Cells = append(t.Rows[i].Cells, "")
+		}
+	}
+}
+
+// ColumnWidths returns the display width of the widest cell in each column
+func (t *Table) ColumnWidths() []int {
+	widths := make([]int, t.Width())
+	for _, row := range t.Rows {
+		for col, cell := range row.Cells {
+			if w := ansi.StringWidth(cell); w > widths[col] {
+				widths[col] = w
+			}
+		}
+	}
+	for col := range widths {
+		if widths[col] == 0 {
+			widths[col] = 1
+		}
+	}
+	return widths
+}
+
+// IsNumericColumn returns true if most non-empty cells in the column are numbers
+// or durations. Like org, such columns are aligned to the right.
+func (t *Table) IsNumericColumn(col int) bool {
+	numbers, filled := 0, 0
+	for _, row := range t.Rows {
+		if row.Rule || col >= len(row.Cells) || row.Cells[col] == "" {
+			continue
+		}
+		filled++
+		if _, _, ok := parseTableNumber(row.Cells[col]); ok {
+			numbers++
+		}
+	}
+	return filled > 0 && numbers*2 >= filled
+}
+
+// Lines returns the table as aligned org text
+func (t *Table) Lines() []string {
+	widths := t.ColumnWidths()
+	numeric := make([]bool, len(widths))
+	for col := range widths {
+		numeric[col] = t.IsNumericColumn(col)
+	}
+
+	var lines []string
+	for _, row := range t.Rows {
+		var b strings.Builder
+		b.WriteString(t.Indent)
+		if row.Rule {
+			b.WriteString("|")
+			for col, w := range widths {
+				if col > 0 {
+					b.WriteString("+")
+				}
+				b.WriteString(strings.Repeat("-", w+2))
+			}
+			b.WriteString("|")
+		} else {
+			for col, w := range widths {
+				cell := ""
+				if col < len(row.Cells) {
+					cell = row.Cells[col]
+				}
+				padding := strings.Repeat(" ", w-ansi.StringWidth(cell))
+				if numeric[col] {
+					b.WriteString("| " + padding + cell + " ")
+				} else {
+					b.WriteString("| " + cell + padding + " ")
+				}
+			}
+			b.WriteString("|")
+		}
+		lines = append(lines, b.String())
+	}
+
+	if len(t.Formulas) > 0 {
+		lines = append(lines, t.Indent+"#+TBLFM: "+strings.Join(t.Formulas, "::"))
+	}
+	return lines
+}
+
+// InsertRow inserts an empty data row at the given index
+func (t *Table) InsertRow(index int) {
+	row := TableRow{Cells: make([]string, t.Width())}
+	t.Rows = append(t.Rows[:index], append([]TableRow{row}, t.Rows[index:]...)...)
+}
+
+// InsertRule inserts a horizontal rule at the given index
+func (t *Table) InsertRule(index int) {
+	t.Rows = append(t.Rows[:index], append([]TableRow{{Rule: true}}, t.Rows[index:]...)...)
+}
+
+// DeleteRow removes the row at the given index
+func (t *Table) DeleteRow(index int) {
+	t.Rows = append(t.Rows[:index], t.Rows[index+1:]...)
+}
+
+// InsertColumn inserts an empty column at the given index
+func (t *Table) InsertColumn(col int) {
+	for i := range t.Rows {
+		if t.Rows[i].Rule {
+			continue
+		}
+		cells := t.Rows[i].Cells
+		t.Rows[i].Cells = append(cells[:col], append([]string{""}, cells[col:]...)...)
+	}
+	t.shiftFormulaColumns(col+1, 1)
+}
+
+// DeleteColumn removes the column at the given index
+func (t *Table) DeleteColumn(col int) {
+	for i := range t.Rows {
+		if t.Rows[i].Rule || col >= len(t.Rows[i].Cells) {
+			continue
+		}
+		t.Rows[i].Cells = append(t.Rows[i].Cells[:col], t.Rows[i].Cells[col+1:]...)
+	}
+	t.shiftFormulaColumns(col+2, -1)
+}
+
+// shiftFormulaColumns moves formula column references ($N) from the given
+// column onwards by delta, keeping them on the same data after a column is
+// inserted or deleted
+func (t *Table) shiftFormulaColumns(from, delta int) {
+	for i, formula := range t.Formulas {
+		t.Formulas[i] = columnRefPattern.ReplaceAllStringFunc(formula, func(ref string) string {
+			n, _ := strconv.Atoi(ref[1:])
+			if n >= from {
+				n += delta
+			}
+			return "$" + strconv.Itoa(n)
+		})
+	}
+}
+
+// ReplaceIn returns the notes with the table's lines replaced by its aligned text
+func (t *Table) ReplaceIn(notes []string) []string {
+	lines := t.Lines()
+	result := append([]string{}, notes[:t.Start]...)
+	result = append(result, lines...)
+	result = append(result, notes[t.End:]...)
+	t.End = t.Start + len(lines)
+	return result
+}
+
+// AlignTables returns the notes with every table realigned
+func AlignTables(notes []string) []string {
+	tables := FindTables(notes)
+	// Replace from the last table so earlier line indices stay valid
+	for i := len(tables) - 1; i >= 0; i-- {
+		notes = tables[i].ReplaceIn(notes)
+	}
+	return notes
+}
+
+var (
+	// formulaPattern matches a column sum or mean: @>$3=vsum(@2..@-1) or $3=vsum(@I..@II)
+	formulaPattern = regexp.MustCompile(`^(@[<>]|@-?\d+)?\$(\d+)\s*=\s*(vsum|vmean)\((\S+)\.\.(\S+)\)$`)
+	// columnRefPattern matches a column reference in a formula
+	columnRefPattern = regexp.MustCompile(`\$\d+`)
+	// durationPattern matches a duration cell such as 1:30 or 12:05:00
+	durationPattern = regexp.MustCompile(`^\d+:\d{2}(:\d{2})?$`)
+)
+
+// parseTableNumber parses a cell as a number or an H:MM[:SS] duration in minutes.
+// isDuration is true if the cell holds a duration.
+func parseTableNumber(cell string) (value float64, isDuration bool, ok bool) {
+	cell = strings.TrimSpace(cell)
+	if durationPattern.MatchString(cell) {
+		parts := strings.Split(cell, ":")
+		hours, _ := strconv.Atoi(parts[0])
+		minutes, _ := strconv.Atoi(parts[1])
+		value = float64(hours*60 + minutes)
+		if len(parts) == 3 {
+			seconds, _ := strconv.Atoi(parts[2])
+			value += float64(seconds) / 60
+		}
+		return value, true, true
+	}
+	value, err := strconv.ParseFloat(cell, 64)
+	return value, false, err == nil
+}
+
+// dataRows returns the indices of the rows that are not horizontal rules
+func (t *Table) dataRows() []int {
+	var rows []int
+	for i, row := range t.Rows {
+		if !row.Rule {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// resolveRowRef resolves an org row reference to an index into Rows. Data rows
+// are numbered from @1, @< and @> are the first and last, @-N and @+N are relative
+// to the row being calculated, and @I, @II... refer to horizontal rules.
+func (t *Table) resolveRowRef(ref string, current int) (int, error) {
+	ref = strings.TrimPrefix(ref, "@")
+	dataRows := t.dataRows()
+	if len(dataRows) == 0 {
+		return 0, fmt.Errorf("table has no rows")
+	}
+
+	switch {
+	case ref == "<":
+		return dataRows[0], nil
+	case ref == ">":
+		return dataRows[len(dataRows)-1], nil
+	case strings.Trim(ref, "I") == "":
+		// Horizontal rule reference: the nth rule
+		count := 0
+		for i, row := range t.Rows {
+			if row.Rule {
+				count++
+				if count == len(ref) {
+					return i, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("no horizontal rule @%s", ref)
+	case strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "+"):
+		offset, err := strconv.Atoi(ref)
+		if err != nil {
+			return 0, fmt.Errorf("invalid row reference @%s", ref)
+		}
+		for pos, row := range dataRows {
+			if row == current {
+				if pos+offset < 0 || pos+offset >= len(dataRows) {
+					return 0, fmt.Errorf("row reference @%s is outside the table", ref)
+				}
+				return dataRows[pos+offset], nil
+			}
+		}
+		return 0, fmt.Errorf("invalid row reference @%s", ref)
+	default:
+		n, err := strconv.Atoi(ref)
+		if err != nil || n < 1 || n > len(dataRows) {
+			return 0, fmt.Errorf("invalid row reference @%s", ref)
+		}
+		return dataRows[n-1], nil
+	}
+}
+
+// Recalculate evaluates the table's formulas. Supported are column sums and means
+// over a range of rows, such as @>$3=vsum(@2..@-1) or $3=vsum(@I..@II); without
+// a row reference the result goes in the last row. Durations (H:MM) are summed as
+// durations, with plain numbers in the same range counted as hours.
+func (t *Table) Recalculate() error {
+	for _, formula := range t.Formulas {
+		matches := formulaPattern.FindStringSubmatch(strings.ReplaceAll(formula, " ", ""))
+		if matches == nil {
+			return fmt.Errorf("unsupported formula: %s", formula)
+		}
+
+		col, _ := strconv.Atoi(matches[2])
+		if col < 1 || col > t.Width() {
+			return fmt.Errorf("column $%d is outside the table", col)
+		}
+		col--
+
+		target := "@>"
+		if matches[1] != "" {
+			target = matches[1]
+		}
+		targetRow, err := t.resolveRowRef(target, -1)
+		if err != nil {
+			return err
+		}
+		if t.Rows[targetRow].Rule {
+			return fmt.Errorf("cannot write %s to a horizontal rule", formula)
+		}
+		from, err := t.resolveRowRef(matches[4], targetRow)
+		if err != nil {
+			return err
+		}
+		to, err := t.resolveRowRef(matches[5], targetRow)
+		if err != nil {
+			return err
+		}
+		if from > to {
+			from, to = to, from
+		}
+
+		var numbers, minutes float64
+		count, durations := 0, false
+		for i := from; i <= to; i++ {
+			row := t.Rows[i]
+			if row.Rule || i == targetRow || col >= len(row.Cells) {
+				continue
+			}
+			if value, isDuration, ok := parseTableNumber(row.Cells[col]); ok {
+				if isDuration {
+					minutes += value
+					durations = true
+				} else {
+					numbers += value
+				}
+				count++
+			}
+		}
+
+		// Plain numbers summed with durations count as hours
+		sum := numbers
+		if durations {
+			sum = numbers*60 + minutes
+		}
+		result := sum
+		if matches[3] == "vmean" && count > 0 {
+			result = sum / float64(count)
+		}
+		t.Rows[targetRow].Cells[col] = formatTableNumber(result, durations)
+	}
+	return nil
+}
+
+// formatTableNumber formats a formula result, as H:MM for durations in minutes
+func formatTableNumber(value float64, isDuration bool) string {
+	if isDuration {
+		d := time.Duration(math.Round(value)) * time.Minute
+		return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
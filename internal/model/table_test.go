@@ -0,0 +1,15 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTableLinesWideCharacters(t *testing.T) {
+	table := FindTables([]string{"| 名前 | x |", "|-+-|", "| ab | \\vert |"})[0]
+
+	want := []string{"| 名前 | x     |", "|------+-------|", "| ab   | \\vert |"}
+	if got := table.Lines(); !slices.Equal(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+// Package notify runs the notification daemon, which reminds of scheduled items
+// and deadlines while the files they are in are watched for changes.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// Notification is a reminder of an item
+type Notification struct {
+	Item    *model.Item
+	Time    time.Time // Time the notification is due
+	Message string
+}
+
+// Clock tells the time and waits, so tests can use a fake one
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the clock of the system
+var SystemClock Clock = systemClock{}
+
+// Daemon checks the loaded files for due notifications each minute and sends
+// them, loading the files again when they change
+type Daemon struct {
+	load         func() (*model.OrgFile, error)
+	send         func(Notification) error
+	clock        Clock
+	lead         time.Duration // Time before a timed scheduled item to notify
+	deadlineTime time.Duration // Time of day to notify of deadlines
+	isDone       func(name string) bool
+	orgFile      *model.OrgFile
+	modTimes     map[string]time.Time // Modification times of the watched paths when last loaded
+	last         time.Time            // Time of the last check
+}
+
+// New creates a daemon sending the notifications of the files load returns
+func New(load func() (*model.OrgFile, error), send func(Notification) error, cfg *config.Config, clock Clock) (*Daemon, error) {
+	deadlineTime, err := time.Parse("15:04", cfg.Notify.DeadlineTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline_time %q, use HH:MM", cfg.Notify.DeadlineTime)
+	}
+	var lead time.Duration
+	if cfg.Notify.LeadMinutes != nil {
+		lead = time.Duration(*cfg.Notify.LeadMinutes) * time.Minute
+	}
+	return &Daemon{
+		load:         load,
+		send:         send,
+		clock:        clock,
+		lead:         lead,
+		deadlineTime: time.Duration(deadlineTime.Hour())*time.Hour + time.Duration(deadlineTime.Minute())*time.Minute,
+		isDone:       cfg.IsDoneState,
+	}, nil
+}
+
+// Run checks for notifications each minute, reporting errors without stopping
+func (d *Daemon) Run(report func(error)) {
+	for {
+		if err := d.Step(); err != nil {
+			report(err)
+		}
+		<-d.clock.After(time.Minute)
+	}
+}
+
+// Step loads the files if they changed and sends the notifications that fell
+// due since the last step. The first step only starts the clock, so nothing
+// due before the daemon started is sent.
+func (d *Daemon) Step() error {
+	now := d.clock.Now()
+	if d.orgFile == nil || d.changed() {
+		orgFile, err := d.load()
+		if err != nil {
+			return fmt.Errorf("loading files: %w", err)
+		}
+		d.orgFile = orgFile
+		d.modTimes = modTimes(watchedPaths(orgFile))
+	}
+
+	if d.last.IsZero() {
+		d.last = now
+		return nil
+	}
+	from := d.last
+	d.last = now
+
+	var errs []error
+	for _, notification := range d.due(model.FlattenAllItems(d.orgFile.Items), from, now) {
+		if err := d.send(notification); err != nil {
+			errs = append(errs, fmt.Errorf("sending %q: %w", notification.Message, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// changed returns true if a watched path changed since the files were loaded
+func (d *Daemon) changed() bool {
+	current := modTimes(watchedPaths(d.orgFile))
+	for path, modTime := range current {
+		if !modTime.Equal(d.modTimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchedPaths returns the files of an org file and the directories they are
+// in, whose modification times change when files are added or removed
+func watchedPaths(orgFile *model.OrgFile) []string {
+	paths := []string{orgFile.Path}
+	if orgFile.IsMultiFile() {
+		seen := make(map[string]bool)
+		for _, fileItem := range model.FileItems(orgFile.Items) {
+			paths = append(paths, fileItem.SourceFile)
+			if dir := filepath.Dir(fileItem.SourceFile); !seen[dir] {
+				seen[dir] = true
+				paths = append(paths, dir)
+			}
+		}
+	}
+	return paths
+}
+
+// modTimes returns the modification times of paths, zero for missing ones
+func modTimes(paths []string) map[string]time.Time {
+	times := make(map[string]time.Time)
+	for _, path := range paths {
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		times[path] = modTime
+	}
+	return times
+}
+
+// due returns the notifications due after from and up to to: the lead time
+// before the time of timed scheduled items, and at the deadline time on the day
+// of deadlines. Items in a final state are left out.
+func (d *Daemon) due(items []*model.Item, from, to time.Time) []Notification {
+	var due []Notification
+	add := func(item *model.Item, at time.Time, message string) {
+		if at.After(from) && !at.After(to) {
+			due = append(due, Notification{Item: item, Time: at, Message: message})
+		}
+	}
+
+	for _, item := range items {
+		if d.isDone(string(item.State)) {
+			continue
+		}
+		if item.Scheduled != nil && hasTime(*item.Scheduled) {
+			scheduled := wallTime(*item.Scheduled, to.Location())
+			add(item, scheduled.Add(-d.lead), fmt.Sprintf("Scheduled at %s: %s", scheduled.Format("15:04"), item.Title))
+		}
+		if item.Deadline != nil {
+			deadline := wallTime(*item.Deadline, to.Location())
+			day := time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, deadline.Location())
+			message := fmt.Sprintf("Deadline today: %s", item.Title)
+			if hasTime(deadline) {
+				message = fmt.Sprintf("Deadline today at %s: %s", deadline.Format("15:04"), item.Title)
+			}
+			add(item, day.Add(d.deadlineTime), message)
+		}
+	}
+	return due
+}
+
+// hasTime returns true if a planning date has a time of day, as org dates
+// without one are read as midnight
+func hasTime(t time.Time) bool {
+	return t.Hour() != 0 || t.Minute() != 0
+}
+
+// wallTime returns the date and time of day of a planning date in loc. Planning
+// dates are read without a time zone, as they are written.
+func wallTime(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+}
+
+// Sender returns the function sending notifications with command: a command run
+// with the message as its last argument, or an http(s) URL the notification is
+// posted to as JSON. Without a command the messages are printed.
+func Sender(command string) func(Notification) error {
+	if strings.HasPrefix(command, "http://") || strings.HasPrefix(command, "https://") {
+		return func(n Notification) error {
+			return postWebhook(command, n)
+		}
+	}
+
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return func(n Notification) error {
+			_, err := fmt.Printf("%s %s\n", n.Time.Format("2006-01-02 15:04"), n.Message)
+			return err
+		}
+	}
+	return func(n Notification) error {
+		return exec.Command(fields[0], append(fields[1:], n.Message)...).Run()
+	}
+}
+
+// webhookClient posts to webhooks, giving up on ones that don't answer so the
+// daemon keeps running
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// postWebhook posts a notification to a webhook URL
+func postWebhook(url string, n Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"title":   n.Item.Title,
+		"message": n.Message,
+		"time":    n.Time.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// fakeClock is a clock that only moves when the test sets it
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time                       { return c.now }
+func (c *fakeClock) After(time.Duration) <-chan time.Time { return nil }
+
+// newTestDaemon creates a daemon over an org file with the given text, recording
+// the messages it sends
+func newTestDaemon(t *testing.T, text string, cfg *config.Config, clock Clock) (*Daemon, string, *[]string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.org")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	load := func() (*model.OrgFile, error) { return parser.ParseOrgFile(path, cfg) }
+	send := func(n Notification) error {
+		sent = append(sent, n.Message)
+		return nil
+	}
+	daemon, err := New(load, send, cfg, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return daemon, path, &sent
+}
+
+// stepAt moves the clock and steps the daemon
+func stepAt(t *testing.T, daemon *Daemon, clock *fakeClock, now time.Time) {
+	t.Helper()
+	clock.now = now
+	if err := daemon.Step(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemonNotifiesOnce(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.Local)
+	}
+	clock := &fakeClock{}
+	daemon, _, sent := newTestDaemon(t, "* TODO Call\nSCHEDULED: <2024-03-01 Fri 14:00>\n"+
+		"* TODO Report\nDEADLINE: <2024-03-02 Sat>\n"+
+		"* DONE Filed\nSCHEDULED: <2024-03-01 Fri 14:00>\n"+
+		"* TODO Someday\nSCHEDULED: <2024-03-01 Fri>\n", config.DefaultConfig(), clock)
+
+	stepAt(t, daemon, clock, at(1, 13, 40))
+	stepAt(t, daemon, clock, at(1, 13, 49))
+	if len(*sent) != 0 {
+		t.Fatalf("sent %q before the lead time", *sent)
+	}
+	stepAt(t, daemon, clock, at(1, 13, 50))
+	stepAt(t, daemon, clock, at(1, 13, 51))
+	if want := []string{"Scheduled at 14:00: Call"}; !slices.Equal(*sent, want) {
+		t.Fatalf("sent %q, want %q", *sent, want)
+	}
+
+	// The daemon may miss minutes, as when the machine sleeps
+	stepAt(t, daemon, clock, at(2, 9, 30))
+	if want := []string{"Scheduled at 14:00: Call", "Deadline today: Report"}; !slices.Equal(*sent, want) {
+		t.Fatalf("sent %q, want %q", *sent, want)
+	}
+}
+
+func TestDaemonReloadsChangedFile(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)}
+	daemon, path, sent := newTestDaemon(t, "* TODO Call\n", config.DefaultConfig(), clock)
+	stepAt(t, daemon, clock, clock.now)
+
+	if err := os.WriteFile(path, []byte("* TODO Call\nSCHEDULED: <2024-03-01 Fri 09:30>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the change is seen on file systems with coarse modification times
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	stepAt(t, daemon, clock, time.Date(2024, 3, 1, 9, 25, 0, 0, time.Local))
+	if want := []string{"Scheduled at 09:30: Call"}; !slices.Equal(*sent, want) {
+		t.Fatalf("sent %q, want %q", *sent, want)
+	}
+}
+
+func TestDaemonSkipsDoneStates(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.States.States = []config.StateConfig{
+		{Name: "TODO"},
+		{Name: "DONE", Done: true},
+		{Name: "CANCELLED", Done: true},
+		{Name: "WAITING"},
+	}
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 13, 0, 0, 0, time.Local)}
+	daemon, _, sent := newTestDaemon(t, "* CANCELLED Call\nSCHEDULED: <2024-03-01 Fri 14:00>\n"+
+		"* DONE Filed\nSCHEDULED: <2024-03-01 Fri 14:00>\n"+
+		"* WAITING Reply\nSCHEDULED: <2024-03-01 Fri 14:00>\n", cfg, clock)
+
+	stepAt(t, daemon, clock, clock.now)
+	stepAt(t, daemon, clock, time.Date(2024, 3, 1, 14, 0, 0, 0, time.Local))
+	if want := []string{"Scheduled at 14:00: Reply"}; !slices.Equal(*sent, want) {
+		t.Fatalf("sent %q, want %q", *sent, want)
+	}
+}
+
+func TestDaemonNoLeadTime(t *testing.T) {
+	cfg := config.DefaultConfig()
+	lead := 0
+	cfg.Notify.LeadMinutes = &lead
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 13, 0, 0, 0, time.Local)}
+	daemon, _, sent := newTestDaemon(t, "* TODO Call\nSCHEDULED: <2024-03-01 Fri 14:00>\n", cfg, clock)
+
+	stepAt(t, daemon, clock, clock.now)
+	stepAt(t, daemon, clock, time.Date(2024, 3, 1, 13, 59, 0, 0, time.Local))
+	if len(*sent) != 0 {
+		t.Fatalf("sent %q before the scheduled time", *sent)
+	}
+	stepAt(t, daemon, clock, time.Date(2024, 3, 1, 14, 0, 0, 0, time.Local))
+	if want := []string{"Scheduled at 14:00: Call"}; !slices.Equal(*sent, want) {
+		t.Fatalf("sent %q, want %q", *sent, want)
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	saved := webhookClient
+	webhookClient = &http.Client{Timeout: 50 * time.Millisecond}
+	defer func() { webhookClient = saved }()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Sender(server.URL)(Notification{Item: &model.Item{Title: "Call"}, Message: "Scheduled at 14:00: Call"})
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("a webhook that doesn't answer returned no error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a webhook that doesn't answer blocked the sender")
+	}
+}
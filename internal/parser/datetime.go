@@ -2,9 +2,15 @@ package parser
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
+// orgDatePrefix matches the date, day name and time at the start of a timestamp,
+// before any time range, repeater or warning period
+var orgDatePrefix = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(?:\s+[^\s\d+.-]+)?(?:\s+(\d{1,2}:\d{2}))?`)
+
 // parseOrgDate parses org-mode date format
 func parseOrgDate(dateStr string) (time.Time, error) {
 	// Org-mode format: 2024-01-15 Mon 10:00
@@ -20,6 +26,14 @@ func parseOrgDate(dateStr string) (time.Time, error) {
 		}
 	}
 
+	// Timestamps such as 2024-01-15 Mon 10:00-11:00 +1w still give their start
+	if matches := orgDatePrefix.FindStringSubmatch(dateStr); matches != nil {
+		if matches[2] == "" {
+			return time.Parse("2006-01-02", matches[1])
+		}
+		return time.Parse("2006-01-02 15:04", matches[1]+" "+matches[2])
+	}
+
 	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
 }
 
@@ -31,8 +45,9 @@ func parseClockTimestamp(timestampStr string) (time.Time, error) {
 		"2006-01-02 Mon 15:04:05",
 	}
 
+	// Clock times are local, like the times clocking in and out records
 	for _, format := range formats {
-		if t, err := time.Parse(format, timestampStr); err == nil {
+		if t, err := time.ParseInLocation(format, timestampStr, time.Local); err == nil {
 			return t, nil
 		}
 	}
@@ -49,3 +64,13 @@ func formatClockTimestamp(t time.Time) string {
 func FormatOrgDate(t time.Time) string {
 	return t.Format("2006-01-02 Mon")
 }
+
+// ParseTimestamp parses an active or inactive org-mode timestamp such as
+// <2024-01-15 Mon> or [2024-01-15 Mon 10:00]
+func ParseTimestamp(timestampStr string) (time.Time, error) {
+	timestampStr = strings.Trim(strings.TrimSpace(timestampStr), "<>[]")
+	if t, err := parseClockTimestamp(timestampStr); err == nil {
+		return t, nil
+	}
+	return parseOrgDate(timestampStr)
+}
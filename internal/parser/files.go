@@ -0,0 +1,324 @@
+package parser
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// ignoreFileName is the file listing the files and directories multi-file mode
+// skips, one gitignore-style pattern per line
+const ignoreFileName = ".orgignore"
+
+// pathRule is a compiled glob pattern from the config or an .orgignore file
+type pathRule struct {
+	base    string         // Directory the pattern is relative to, as a slash path relative to the root
+	pattern *regexp.Regexp // Matches slash paths relative to base
+	negate  bool           // The pattern started with ! and re-includes what it matches
+	dirOnly bool           // The pattern ended with / and only matches directories
+}
+
+// dirLoader finds and parses the org files below a directory
+type dirLoader struct {
+	cfg       *config.Config
+	recursive bool
+	include   []pathRule
+	exclude   []pathRule
+	skip      map[string]bool // Absolute paths of files not to load again
+}
+
+// newDirLoader creates a loader using the include and exclude patterns of the config
+func newDirLoader(cfg *config.Config, recursive bool) *dirLoader {
+	loader := &dirLoader{cfg: cfg, recursive: recursive}
+
+	include := cfg.Files.Include
+	if cfg.Files.IncludeArchive {
+		include = append(append([]string{}, include...), "*.org_archive")
+	}
+	for _, pattern := range include {
+		if rule, ok := compilePathRule(pattern, ""); ok {
+			loader.include = append(loader.include, rule)
+		}
+	}
+	for _, pattern := range cfg.Files.Exclude {
+		if rule, ok := compilePathRule(pattern, ""); ok {
+			loader.exclude = append(loader.exclude, rule)
+		}
+	}
+	return loader
+}
+
+// load returns the items for the files in dirPath at the given level, followed by
+// items for its subdirectories when loading recursively. rel is the slash path of
+// dirPath relative to the root, and rules the exclude rules of its parents.
+func (l *dirLoader) load(dirPath, rel string, level int, rules []pathRule) ([]*model.Item, error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	rules = append(rules[:len(rules):len(rules)], readIgnoreFile(filepath.Join(dirPath, ignoreFileName), rel)...)
+
+	var items, dirs []*model.Item
+	for _, entry := range entries {
+		name := entry.Name()
+		entryRel := path.Join(rel, name)
+		entryPath := filepath.Join(dirPath, name)
+
+		if entry.IsDir() {
+			// Hidden directories such as .git are never searched
+			if !l.recursive || strings.HasPrefix(name, ".") || ignored(rules, entryRel, true) {
+				continue
+			}
+			children, err := l.load(entryPath, entryRel, level+1, rules)
+			if err != nil || len(children) == 0 {
+				// Skip directories that can't be read or hold no org files
+				continue
+			}
+			dirs = append(dirs, &model.Item{
+				Level:      level,
+				State:      model.StateNone,
+				Priority:   model.PriorityNone,
+				Title:      name + "/",
+				Tags:       []string{},
+				Children:   children,
+				SourceFile: entryPath,
+				Wrapper:    model.DirWrapper,
+			})
+			continue
+		}
+
+		if !included(l.include, entryRel) || ignored(rules, entryRel, false) || l.skip[absPath(entryPath)] {
+			continue
+		}
+		fileItem, err := parseFileItem(entryPath, level, l.cfg)
+		if err != nil {
+			// Skip files that can't be parsed
+			continue
+		}
+		if l.skip != nil {
+			// Files reached through several agenda paths are loaded once
+			l.skip[absPath(entryPath)] = true
+		}
+		items = append(items, fileItem)
+	}
+
+	// Files come before the subdirectories, so the top-level files stay first
+	return append(items, dirs...), nil
+}
+
+// ParseAgendaFiles loads the files and directories listed as agenda files, each
+// as a top-level item, with the org files below directories loaded recursively.
+// Paths may start with ~/ for the home directory. Files in skip, which are
+// already loaded elsewhere, and paths that don't exist are left out.
+func ParseAgendaFiles(paths []string, skip []string, cfg *config.Config) (*model.OrgFile, error) {
+	loader := newDirLoader(cfg, true)
+	loader.skip = make(map[string]bool)
+	for _, path := range skip {
+		loader.skip[absPath(path)] = true
+	}
+
+	agendaFile := &model.OrgFile{Items: []*model.Item{}}
+	for _, path := range paths {
+		path, err := expandHome(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			children, err := loader.load(path, "", 2, loader.exclude)
+			if err != nil || len(children) == 0 {
+				continue
+			}
+			agendaFile.Items = append(agendaFile.Items, &model.Item{
+				Level:      1,
+				State:      model.StateNone,
+				Priority:   model.PriorityNone,
+				Title:      filepath.Base(filepath.Clean(path)) + "/",
+				Tags:       []string{},
+				Children:   children,
+				SourceFile: path,
+				Wrapper:    model.DirWrapper,
+			})
+			continue
+		}
+
+		if loader.skip[absPath(path)] {
+			continue
+		}
+		fileItem, err := parseFileItem(path, 1, cfg)
+		if err != nil {
+			continue
+		}
+		// A file listed on its own is not loaded again from a listed directory
+		loader.skip[absPath(path)] = true
+		agendaFile.Items = append(agendaFile.Items, fileItem)
+	}
+
+	agendaFile.BuildIDIndex()
+	if err := markSaved(agendaFile); err != nil {
+		return nil, err
+	}
+	return agendaFile, nil
+}
+
+// expandHome replaces a leading ~/ in a path with the home directory
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+// absPath returns the cleaned absolute form of a path, for comparing paths
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// readIgnoreFile reads the rules of an .orgignore file, relative to the directory
+// it is in. A missing file has no rules.
+func readIgnoreFile(filePath, base string) []pathRule {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []pathRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := compilePathRule(scanner.Text(), base); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// included returns true if a file matches any of the include rules
+func included(rules []pathRule, rel string) bool {
+	for _, rule := range rules {
+		if rule.matches(rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored returns true if a path is excluded. As in .gitignore, the last rule
+// matching the path decides, so later ! patterns re-include it.
+func ignored(rules []pathRule, rel string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.matches(rel, isDir) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// matches returns true if the rule matches the slash path rel, relative to the root
+func (r pathRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, r.base+"/")
+	}
+	return r.pattern.MatchString(rel)
+}
+
+// compilePathRule compiles a gitignore-style glob pattern. Patterns without a
+// slash match a name at any depth, others match the path from base. * and ?
+// stay within one path segment, while ** matches any number of directories.
+// Blank lines and # comments give no rule.
+func compilePathRule(line, base string) (pathRule, bool) {
+	pattern := strings.TrimSpace(line)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return pathRule{}, false
+	}
+
+	rule := pathRule{base: base}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if pattern == "" {
+		return pathRule{}, false
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		expr.WriteString("(?:.*/)?")
+	}
+	expr.WriteString(globToRegexp(pattern))
+	expr.WriteString("$")
+
+	compiled, err := regexp.Compile(expr.String())
+	if err != nil {
+		return pathRule{}, false
+	}
+	rule.pattern = compiled
+	return rule, true
+}
+
+// globToRegexp converts the wildcards of a glob pattern to regular expression syntax
+func globToRegexp(pattern string) string {
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				expr.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+var (
+	propertyLinePattern = regexp.MustCompile(`^\s*:(\S+):(?:\s+(.*?))?\s*$`)
+	clockLinePattern    = regexp.MustCompile(`^\s*CLOCK:`)
+)
+
+// parseMetadata moves the planning lines and drawers directly below an item's
+// heading out of its notes and into its fields, leaving the body text as notes.
+// They are taken in any order, up to the first line that is none of them, and
+// CLOCK lines there count as logbook entries even outside a :LOGBOOK: drawer.
+func parseMetadata(item *model.Item) {
+	lines := item.Notes
+	n := 0
+scan:
+	for n < len(lines) {
+		line := lines[n]
+		switch {
+		case planningLinePattern.MatchString(line):
+			parsePlanningLine(item, line)
+			n++
+		case propertiesDrawerStart.MatchString(line):
+			end := drawerEndIndex(lines, n)
+			if end < 0 || !parseProperties(item, lines[n+1:end]) {
+				break scan
+			}
+			n = end + 1
+		case logbookDrawerStart.MatchString(line):
+			end := drawerEndIndex(lines, n)
+			if end < 0 {
+				break scan
+			}
+			parseLogbook(item, lines[n+1:end])
+			n = end + 1
+		case clockLinePattern.MatchString(line):
+			parseLogbook(item, lines[n:n+1])
+			n++
+		default:
+			break scan
+		}
+	}
+
+	item.Notes = append([]string{}, lines[n:]...)
+	item.RawMetadata = model.RawMetadata{Lines: append([]string{}, lines[:n]...)}
+	item.RawMetadata.Formatted = formatMetadata(item)
+}
+
+// drawerEndIndex returns the index of the :END: line closing the drawer opened at
+// start, or -1 if it is not closed
+func drawerEndIndex(lines []string, start int) int {
+	for i := start + 1; i < len(lines); i++ {
+		if drawerEnd.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseProperties adds the entries of a :PROPERTIES: drawer to an item. It returns
+// false, adding nothing, if a line in the drawer isn't a property.
+func parseProperties(item *model.Item, lines []string) bool {
+	var properties []model.Property
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		matches := propertyLinePattern.FindStringSubmatch(line)
+		if matches == nil {
+			return false
+		}
+		properties = append(properties, model.Property{Name: matches[1], Value: matches[2]})
+	}
+	item.Properties = append(item.Properties, properties...)
+	return true
+}
+
+// parseLogbook adds the clock entries and other lines of a :LOGBOOK: drawer to an item
+func parseLogbook(item *model.Item, lines []string) {
+	for _, line := range lines {
+		if entry, ok := parseClockLine(line); ok {
+			item.ClockEntries = append(item.ClockEntries, entry)
+			continue
+		}
+		item.LogbookNotes = append(item.LogbookNotes, model.LogbookNote{
+			Line:   line,
+			Clocks: len(item.ClockEntries),
+		})
+	}
+}
+
+// parseClockLine parses a CLOCK line into a clock entry
+func parseClockLine(line string) (model.ClockEntry, bool) {
+	if !clockLinePattern.MatchString(line) {
+		return model.ClockEntry{}, false
+	}
+	matches := clockPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return model.ClockEntry{}, false
+	}
+	start, err := parseClockTimestamp(matches[1])
+	if err != nil {
+		return model.ClockEntry{}, false
+	}
+	entry := model.ClockEntry{Start: start}
+	if matches[2] != "" {
+		if end, err := parseClockTimestamp(matches[2]); err == nil {
+			entry.End = &end
+		}
+	}
+	return entry, true
+}
+
+// metadataLines returns the planning line and drawers to write below an item's
+// heading. The lines as read are kept while the fields still format to the same.
+func metadataLines(item *model.Item) []string {
+	lines := formatMetadata(item)
+	if slices.Equal(lines, item.RawMetadata.Formatted) {
+		return item.RawMetadata.Lines
+	}
+	return lines
+}
+
+// formatMetadata formats an item's planning line, :PROPERTIES: drawer and
+// :LOGBOOK: drawer, in that order. Property and clock lines read from the file
+// are reused while they still say the same, so their alignment is kept.
+func formatMetadata(item *model.Item) []string {
+	indent := ""
+	if len(item.RawMetadata.Lines) > 0 {
+		first := item.RawMetadata.Lines[0]
+		indent = first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	}
+
+	var lines []string
+	if planning := formatPlanningLine(item, indent); planning != "" {
+		lines = append(lines, planning)
+	}
+
+	if len(item.Properties) > 0 {
+		lines = append(lines, indent+":PROPERTIES:")
+		for _, property := range item.Properties {
+			lines = append(lines, formatPropertyLine(item, property, indent))
+		}
+		lines = append(lines, indent+":END:")
+	}
+
+	if len(item.ClockEntries) > 0 || len(item.LogbookNotes) > 0 {
+		lines = append(lines, indent+":LOGBOOK:")
+		notes := item.LogbookNotes
+		for i, entry := range item.ClockEntries {
+			for len(notes) > 0 && notes[0].Clocks <= i {
+				lines = append(lines, formatLogbookNote(item, notes[0], indent))
+				notes = notes[1:]
+			}
+			lines = append(lines, formatClockLine(item, entry, indent))
+		}
+		for _, note := range notes {
+			lines = append(lines, formatLogbookNote(item, note, indent))
+		}
+		lines = append(lines, indent+":END:")
+	}
+	return lines
+}
+
+// formatPropertyLine formats a :PROPERTIES: drawer entry
+func formatPropertyLine(item *model.Item, property model.Property, indent string) string {
+	for _, line := range item.RawMetadata.Lines {
+		matches := propertyLinePattern.FindStringSubmatch(line)
+		if matches != nil && matches[1] == property.Name && matches[2] == property.Value {
+			return line
+		}
+	}
+	if property.Value == "" {
+		return fmt.Sprintf("%s:%s:", indent, property.Name)
+	}
+	return fmt.Sprintf("%s:%s: %s", indent, property.Name, property.Value)
+}
+
+// formatClockLine formats a :LOGBOOK: clock entry
+func formatClockLine(item *model.Item, entry model.ClockEntry, indent string) string {
+	for _, line := range item.RawMetadata.Lines {
+		if read, ok := parseClockLine(line); ok && sameClockEntry(read, entry) {
+			return line
+		}
+	}
+	line := fmt.Sprintf("%sCLOCK: [%s]", indent, formatClockTimestamp(entry.Start))
+	if entry.End != nil {
+		line += fmt.Sprintf("--[%s] => %s", formatClockTimestamp(*entry.End), formatClockDuration(entry))
+	}
+	return line
+}
+
+// formatLogbookNote formats a :LOGBOOK: line other than a clock entry. Lines read
+// from the file are kept as they are; new ones are indented like the drawer.
+func formatLogbookNote(item *model.Item, note model.LogbookNote, indent string) string {
+	if slices.Contains(item.RawMetadata.Lines, note.Line) {
+		return note.Line
+	}
+	return indent + strings.TrimLeft(note.Line, " \t")
+}
+
+// formatClockDuration formats the duration of a closed clock entry as org does,
+// with the hours right-aligned in two columns (" 1:30"). It is taken from the
+// timestamps as written, to the minute.
+func formatClockDuration(entry model.ClockEntry) string {
+	minutes := int(entry.End.Truncate(time.Minute).Sub(entry.Start.Truncate(time.Minute)) / time.Minute)
+	if minutes < 0 {
+		minutes = 0
+	}
+	return fmt.Sprintf("%2d:%02d", minutes/60, minutes%60)
+}
+
+// sameClockEntry returns true if two clock entries cover the same minutes
+func sameClockEntry(a, b model.ClockEntry) bool {
+	if formatClockTimestamp(a.Start) != formatClockTimestamp(b.Start) {
+		return false
+	}
+	if a.End == nil || b.End == nil {
+		return a.End == nil && b.End == nil
+	}
+	return formatClockTimestamp(*a.End) == formatClockTimestamp(*b.End)
+}
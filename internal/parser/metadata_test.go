@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+func TestMetadataSplitFromBody(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		"DEADLINE: <2024-03-01 Fri>\n"+
+		":PROPERTIES:\n:Effort:   1:30\n:END:\n"+
+		":LOGBOOK:\n- Note taken on [2024-02-25 Sun 10:00]\nCLOCK: [2024-02-24 Sat 10:00]--[2024-02-24 Sat 11:00] =>  1:00\n:END:\n"+
+		"The DEADLINE: of the grant is not this task's.\n"+
+		":LOGBOOK:\n:END:\n")
+
+	orgFile, _ := roundTrip(t, path, nil)
+	item := orgFile.Items[0]
+
+	if item.Deadline == nil || item.Deadline.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("deadline = %v, want 2024-03-01", item.Deadline)
+	}
+	if got := item.GetEffort(); got != "1:30" {
+		t.Errorf("effort = %q, want 1:30", got)
+	}
+	if len(item.ClockEntries) != 1 || len(item.LogbookNotes) != 1 {
+		t.Errorf("got %d clock entries and %d logbook notes, want 1 and 1", len(item.ClockEntries), len(item.LogbookNotes))
+	}
+	want := []string{"The DEADLINE: of the grant is not this task's.", ":LOGBOOK:", ":END:"}
+	if len(item.Notes) != len(want) {
+		t.Fatalf("notes = %q, want %q", item.Notes, want)
+	}
+	for i := range want {
+		if item.Notes[i] != want[i] {
+			t.Errorf("notes = %q, want %q", item.Notes, want)
+			break
+		}
+	}
+}
+
+func TestDeadlineMentionedInBody(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\nThe DEADLINE: of the grant is not this task's.\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		deadline := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		orgFile.Items[0].Deadline = &deadline
+	})
+
+	want := "* TODO Task\nDEADLINE: <2024-03-01 Fri>\nThe DEADLINE: of the grant is not this task's.\n"
+	if written != want {
+		t.Errorf("deadline with a mention in the body, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestClockInWithExistingLogbook(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		":PROPERTIES:\n:ID:       abc\n:END:\n"+
+		":LOGBOOK:\n"+
+		"CLOCK: [2024-02-24 Sat 10:00]--[2024-02-24 Sat 11:00] =>  1:00\n"+
+		"- State \"TODO\"       from              [2024-02-23 Fri 09:00]\n"+
+		":END:\n"+
+		"Body\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		item := orgFile.Items[0]
+		item.ClockIn()
+		item.ClockEntries[0].Start = time.Date(2024, 2, 26, 9, 0, 0, 0, time.UTC)
+		item.SetProperty("EFFORT", "2:00")
+	})
+
+	want := "* TODO Task\n" +
+		":PROPERTIES:\n:ID:       abc\n:EFFORT: 2:00\n:END:\n" +
+		":LOGBOOK:\n" +
+		"CLOCK: [2024-02-26 Mon 09:00]\n" +
+		"CLOCK: [2024-02-24 Sat 10:00]--[2024-02-24 Sat 11:00] =>  1:00\n" +
+		"- State \"TODO\"       from              [2024-02-23 Fri 09:00]\n" +
+		":END:\n" +
+		"Body\n"
+	if written != want {
+		t.Errorf("clock in with an existing logbook, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestClockInOutNewestFirst(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		"  :LOGBOOK:\n"+
+		"  - Note taken on [2024-02-25 Sun 18:00]\n"+
+		"  CLOCK: [2024-02-25 Sun 14:00]--[2024-02-25 Sun 14:45] =>  0:45\n"+
+		"  :END:\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		item := orgFile.Items[0]
+		if !item.ClockIn() {
+			t.Fatal("ClockIn returned false")
+		}
+		item.ClockEntries[0].Start = time.Date(2024, 2, 26, 9, 0, 0, 0, time.UTC)
+		if !item.ClockOut() {
+			t.Fatal("ClockOut returned false")
+		}
+		end := time.Date(2024, 2, 26, 19, 5, 30, 0, time.UTC)
+		item.ClockEntries[0].End = &end
+	})
+
+	want := "* TODO Task\n" +
+		"  :LOGBOOK:\n" +
+		"  CLOCK: [2024-02-26 Mon 09:00]--[2024-02-26 Mon 19:05] => 10:05\n" +
+		"  - Note taken on [2024-02-25 Sun 18:00]\n" +
+		"  CLOCK: [2024-02-25 Sun 14:00]--[2024-02-25 Sun 14:45] =>  0:45\n" +
+		"  :END:\n"
+	if written != want {
+		t.Errorf("clock in and out, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestNewLogbookNoteIndented(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		"  :LOGBOOK:\n"+
+		"  CLOCK: [2024-02-25 Sun 14:00]--[2024-02-25 Sun 14:25] =>  0:25\n"+
+		"- Note taken on [2024-02-25 Sun 18:00]\n"+
+		"  :END:\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		orgFile.Items[0].AddLogbookNote("- Pomodoro completed on [2024-02-25 Sun 14:25]")
+	})
+
+	want := "* TODO Task\n" +
+		"  :LOGBOOK:\n" +
+		"  - Pomodoro completed on [2024-02-25 Sun 14:25]\n" +
+		"  CLOCK: [2024-02-25 Sun 14:00]--[2024-02-25 Sun 14:25] =>  0:25\n" +
+		"- Note taken on [2024-02-25 Sun 18:00]\n" +
+		"  :END:\n"
+	if written != want {
+		t.Errorf("new logbook note, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestLegacyEffortLine(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n:EFFORT: 2h\nBody\n")
+
+	orgFile, written := roundTrip(t, path, nil)
+	if got := orgFile.Items[0].GetEffort(); got != "2h" {
+		t.Errorf("effort outside a drawer = %q, want 2h", got)
+	}
+	if want := "* TODO Task\n:EFFORT: 2h\nBody\n"; written != want {
+		t.Errorf("unchanged legacy effort, first difference at %s", diffLine(want, written))
+	}
+
+	_, written = roundTrip(t, path, func(orgFile *model.OrgFile) {
+		orgFile.Items[0].SetEffort("3h")
+	})
+	if want := "* TODO Task\n:PROPERTIES:\n:EFFORT: 3h\n:END:\nBody\n"; written != want {
+		t.Errorf("legacy effort set again, first difference at %s", diffLine(want, written))
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 
 	"github.com/rwejlgaard/org/internal/config"
@@ -18,12 +17,9 @@ var (
 	deadlinePattern       = regexp.MustCompile(`DEADLINE:\s*<([^>]+)>`)
 	closedPattern         = regexp.MustCompile(`CLOSED:\s*\[([^\]]+)\]`)
 	clockPattern          = regexp.MustCompile(`CLOCK:\s*\[([^\]]+)\](?:--\[([^\]]+)\])?`)
-	effortPattern         = regexp.MustCompile(`^\s*:EFFORT:\s*(.+)$`)
 	logbookDrawerStart    = regexp.MustCompile(`^\s*:LOGBOOK:\s*$`)
 	propertiesDrawerStart = regexp.MustCompile(`^\s*:PROPERTIES:\s*$`)
 	drawerEnd             = regexp.MustCompile(`^\s*:END:\s*$`)
-	codeBlockStart        = regexp.MustCompile(`^\s*#\+BEGIN_SRC`)
-	codeBlockEnd          = regexp.MustCompile(`^\s*#\+END_SRC`)
 )
 
 // buildHeadingPattern creates a regex pattern that matches configured states
@@ -48,6 +44,19 @@ func buildHeadingPattern(cfg *config.Config) *regexp.Regexp {
 
 // ParseOrgFile reads and parses an org-mode file
 func ParseOrgFile(path string, cfg *config.Config) (*model.OrgFile, error) {
+	orgFile, err := parseOrgFile(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := markSaved(orgFile); err != nil {
+		return nil, err
+	}
+	return orgFile, nil
+}
+
+// parseOrgFile parses an org-mode file without noting its text as saved, for
+// files loaded as part of a larger org file
+func parseOrgFile(path string, cfg *config.Config) (*model.OrgFile, error) {
 	headingPattern := buildHeadingPattern(cfg)
 	file, err := os.Open(path)
 	if err != nil {
@@ -62,68 +71,38 @@ func ParseOrgFile(path string, cfg *config.Config) (*model.OrgFile, error) {
 	orgFile := &model.OrgFile{Path: path, Items: []*model.Item{}}
 	scanner := bufio.NewScanner(file)
 
-	var currentItem *model.Item
-	var itemStack []*model.Item // Stack to track parent items
-	var inCodeBlock bool
-	var inLogbookDrawer bool
-	var inPropertiesDrawer bool
-
+	// Read all lines first so a block's start can be checked for a matching end
+	var lines []string
 	for scanner.Scan() {
-		line := scanner.Text()
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
-		// Check for drawer boundaries
-		if logbookDrawerStart.MatchString(line) {
-			inLogbookDrawer = true
-			if currentItem != nil {
-				currentItem.Notes = append(currentItem.Notes, line)
-			}
-			continue
-		}
-		if propertiesDrawerStart.MatchString(line) {
-			inPropertiesDrawer = true
-			if currentItem != nil {
-				currentItem.Notes = append(currentItem.Notes, line)
-			}
-			continue
-		}
-		if drawerEnd.MatchString(line) {
-			if inLogbookDrawer {
-				inLogbookDrawer = false
-				if currentItem != nil {
-					currentItem.Notes = append(currentItem.Notes, line)
-				}
-				continue
-			}
-			if inPropertiesDrawer {
-				inPropertiesDrawer = false
-				if currentItem != nil {
-					currentItem.Notes = append(currentItem.Notes, line)
-				}
-				continue
-			}
-		}
+	var currentItem *model.Item
+	var itemStack []*model.Item // Stack to track parent items
+	var blockName string        // Name of the open #+BEGIN_ block, "" outside blocks
 
-		// Check for code block boundaries
-		if codeBlockStart.MatchString(line) {
-			inCodeBlock = true
-			if currentItem != nil {
-				currentItem.Notes = append(currentItem.Notes, line)
+	for i, line := range lines {
+		// Blocks are kept verbatim, so lines in them that look like headings or
+		// drawers are not parsed. This applies before the first heading too.
+		if blockName != "" {
+			if name, ok := model.BlockEnd(line); ok && name == blockName {
+				blockName = ""
 			}
+			appendContentLine(orgFile, currentItem, line)
 			continue
 		}
-		if codeBlockEnd.MatchString(line) {
-			inCodeBlock = false
-			if currentItem != nil {
-				currentItem.Notes = append(currentItem.Notes, line)
-			}
+		if name, ok := model.BlockBegin(line); ok && model.HasBlockEnd(lines[i+1:], name) {
+			blockName = name
+			appendContentLine(orgFile, currentItem, line)
 			continue
 		}
 
-		// If in code block, add line to notes
-		if inCodeBlock {
-			if currentItem != nil {
-				currentItem.Notes = append(currentItem.Notes, line)
-			}
+		// Keep lines before the first heading as the file preamble
+		if currentItem == nil && !headingPattern.MatchString(line) {
+			orgFile.Preamble = append(orgFile.Preamble, line)
 			continue
 		}
 
@@ -153,6 +132,10 @@ func ParseOrgFile(path string, cfg *config.Config) (*model.OrgFile, error) {
 				Notes:    []string{},
 				Children: []*model.Item{},
 			}
+			item.RawHeading = model.RawHeading{
+				Line:      strings.TrimPrefix(line, matches[1]),
+				Formatted: formatHeadingRest(item),
+			}
 
 			// Find parent based on level
 			for len(itemStack) > 0 && itemStack[len(itemStack)-1].Level >= level {
@@ -171,118 +154,89 @@ func ParseOrgFile(path string, cfg *config.Config) (*model.OrgFile, error) {
 			itemStack = append(itemStack, item)
 			currentItem = item
 		} else if currentItem != nil {
-			// This is content under the current item
-			trimmed := strings.TrimSpace(line)
-
-			// Check for SCHEDULED
-			if matches := scheduledPattern.FindStringSubmatch(line); matches != nil {
-				if t, err := parseOrgDate(matches[1]); err == nil {
-					currentItem.Scheduled = &t
-				}
-			}
-
-			// Check for DEADLINE
-			if matches := deadlinePattern.FindStringSubmatch(line); matches != nil {
-				if t, err := parseOrgDate(matches[1]); err == nil {
-					currentItem.Deadline = &t
-				}
-			}
-
-			// Check for CLOSED
-			if matches := closedPattern.FindStringSubmatch(line); matches != nil {
-				if t, err := parseClockTimestamp(matches[1]); err == nil {
-					currentItem.Closed = &t
-				}
-			}
-
-			// Check for EFFORT (inside PROPERTIES drawer)
-			if matches := effortPattern.FindStringSubmatch(line); matches != nil {
-				currentItem.Effort = strings.TrimSpace(matches[1])
-			}
-
-			// Check for CLOCK (can be inside or outside drawer)
-			if matches := clockPattern.FindStringSubmatch(line); matches != nil {
-				if startTime, err := parseClockTimestamp(matches[1]); err == nil {
-					entry := model.ClockEntry{Start: startTime}
-					if len(matches) > 2 && matches[2] != "" {
-						if endTime, err := parseClockTimestamp(matches[2]); err == nil {
-							entry.End = &endTime
-						}
-					}
-					currentItem.ClockEntries = append(currentItem.ClockEntries, entry)
-				}
-			}
-
-			// Add all lines as notes (including scheduling lines and drawer content for proper serialization)
-			if trimmed != "" || len(currentItem.Notes) > 0 {
-				currentItem.Notes = append(currentItem.Notes, line)
-			}
+			// Add all lines as notes for now, including blank lines so the file is
+			// written back as it was read. The planning line and drawers are moved
+			// into the item's fields below.
+			currentItem.Notes = append(currentItem.Notes, line)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	for _, item := range model.FlattenAllItems(orgFile.Items) {
+		parseMetadata(item)
 	}
 
+	orgFile.BuildIDIndex()
 	return orgFile, nil
 }
 
-// ParseMultipleOrgFiles loads all .org files in a directory and wraps them as top-level items
-func ParseMultipleOrgFiles(dirPath string, cfg *config.Config) (*model.OrgFile, error) {
-	// Find all .org files in the directory
-	matches, err := filepath.Glob(filepath.Join(dirPath, "*.org"))
+// appendContentLine adds a line to the current item's notes, or to the file's
+// preamble before the first heading
+func appendContentLine(orgFile *model.OrgFile, currentItem *model.Item, line string) {
+	if currentItem != nil {
+		currentItem.Notes = append(currentItem.Notes, line)
+	} else {
+		orgFile.Preamble = append(orgFile.Preamble, line)
+	}
+}
+
+// ParseMultipleOrgFiles loads the org files in a directory and wraps them as
+// top-level items. With recursive set, subdirectories are loaded as well, each
+// as a directory item holding its own files and subdirectories.
+func ParseMultipleOrgFiles(dirPath string, cfg *config.Config, recursive bool) (*model.OrgFile, error) {
+	loader := newDirLoader(cfg, recursive)
+	items, err := loader.load(dirPath, "", 1, loader.exclude)
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort files alphabetically
-	sort.Strings(matches)
-
 	// Create a virtual org file
 	multiOrgFile := &model.OrgFile{
 		Path:  dirPath, // Store directory path
-		Items: []*model.Item{},
+		Items: items,
 	}
 
-	// Parse each file and wrap it as a top-level item
-	for _, filePath := range matches {
-		orgFile, err := ParseOrgFile(filePath, cfg)
-		if err != nil {
-			// Skip files that can't be parsed
-			continue
-		}
-
-		// Create a wrapper item for this file
-		fileName := filepath.Base(filePath)
-		fileItem := &model.Item{
-			Level:      1,
-			State:      model.StateNone,
-			Priority:   model.PriorityNone,
-			Title:      fileName,
-			Tags:       []string{},
-			Notes:      []string{},
-			Children:   []*model.Item{},
-			SourceFile: filePath,
-		}
+	// Index IDs across all files so id: links resolve between them
+	multiOrgFile.BuildIDIndex()
+	if err := markSaved(multiOrgFile); err != nil {
+		return nil, err
+	}
+	return multiOrgFile, nil
+}
 
-		// Increment the level of all items from this file and add as children
-		for _, item := range orgFile.Items {
-			incrementItemLevel(item)
-			setSourceFileRecursive(item, filePath)
-			fileItem.Children = append(fileItem.Children, item)
-		}
+// parseFileItem parses an org file and wraps it as an item at the given level,
+// with the file's items below it
+func parseFileItem(filePath string, level int, cfg *config.Config) (*model.Item, error) {
+	orgFile, err := parseOrgFile(filePath, cfg)
+	if err != nil {
+		return nil, err
+	}
 
-		multiOrgFile.Items = append(multiOrgFile.Items, fileItem)
+	fileItem := &model.Item{
+		Level:      level,
+		State:      model.StateNone,
+		Priority:   model.PriorityNone,
+		Title:      filepath.Base(filePath),
+		Tags:       []string{},
+		Notes:      orgFile.Preamble, // The file's preamble is kept as the wrapper's notes
+		Children:   []*model.Item{},
+		SourceFile: filePath,
+		Wrapper:    model.FileWrapper,
 	}
 
-	return multiOrgFile, nil
+	// Shift the level of all items from this file below the wrapper and add as children
+	for _, item := range orgFile.Items {
+		shiftItemLevel(item, level)
+		setSourceFileRecursive(item, filePath)
+		fileItem.Children = append(fileItem.Children, item)
+	}
+	return fileItem, nil
 }
 
-// incrementItemLevel recursively increments the level of an item and its children
-func incrementItemLevel(item *model.Item) {
-	item.Level++
+// shiftItemLevel recursively shifts the level of an item and its children by delta
+func shiftItemLevel(item *model.Item, delta int) {
+	item.Level += delta
 	for _, child := range item.Children {
-		incrementItemLevel(child)
+		shiftItemLevel(child, delta)
 	}
 }
 
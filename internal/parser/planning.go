@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+var (
+	planningEntryPattern = regexp.MustCompile(`(CLOSED|DEADLINE|SCHEDULED):\s*([<\[][^>\]]*[>\]])`)
+	planningLinePattern  = regexp.MustCompile(`^\s*(?:(?:CLOSED|DEADLINE|SCHEDULED):\s*[<\[][^>\]]*[>\]]\s*)+$`)
+)
+
+// planningKeywords are the planning keywords in the order org writes them
+var planningKeywords = []string{"CLOSED", "DEADLINE", "SCHEDULED"}
+
+// parsePlanningLine sets an item's dates from a planning line
+func parsePlanningLine(item *model.Item, line string) {
+	if matches := scheduledPattern.FindStringSubmatch(line); matches != nil {
+		if t, err := parseOrgDate(matches[1]); err == nil {
+			item.Scheduled = &t
+		}
+	}
+	if matches := deadlinePattern.FindStringSubmatch(line); matches != nil {
+		if t, err := parseOrgDate(matches[1]); err == nil {
+			item.Deadline = &t
+		}
+	}
+	if matches := closedPattern.FindStringSubmatch(line); matches != nil {
+		if t, err := parseClockTimestamp(matches[1]); err == nil {
+			item.Closed = &t
+		}
+	}
+}
+
+// formatPlanningLine returns the planning line for an item's CLOSED, DEADLINE and
+// SCHEDULED dates, in org's canonical order, or "" if it has none. Timestamps read
+// from the file that still hold the dates keep their text, so times and repeaters
+// survive, and a planning line read from the file that still holds all of them is
+// kept as it is.
+func formatPlanningLine(item *model.Item, indent string) string {
+	existing := make(map[string]string)
+	var read []string
+	for _, line := range item.RawMetadata.Lines {
+		if !planningLinePattern.MatchString(line) {
+			continue
+		}
+		read = append(read, line)
+		for _, match := range planningEntryPattern.FindAllStringSubmatch(line, -1) {
+			existing[match[1]] = match[2]
+		}
+	}
+
+	values := map[string]*time.Time{
+		"CLOSED":    item.Closed,
+		"DEADLINE":  item.Deadline,
+		"SCHEDULED": item.Scheduled,
+	}
+	var entries []string
+	unchanged := true
+	for _, keyword := range planningKeywords {
+		text, ok := existing[keyword]
+		value := values[keyword]
+		if ok && planningTimestampMatches(keyword, text, value) {
+			// Timestamps the parser couldn't read are kept as they are
+			entries = append(entries, keyword+": "+text)
+			continue
+		}
+		unchanged = unchanged && !ok && value == nil
+		if value != nil {
+			entries = append(entries, keyword+": "+formatPlanningTimestamp(keyword, *value))
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	if unchanged && len(read) == 1 {
+		return read[0]
+	}
+	return indent + strings.Join(entries, " ")
+}
+
+// planningTimestampMatches returns true if a planning timestamp's text still
+// holds value. Text the parser can't read never set a field, so it only matches
+// a nil value.
+func planningTimestampMatches(keyword, text string, value *time.Time) bool {
+	t, ok := parsePlanningTimestamp(keyword, text)
+	if !ok {
+		return value == nil
+	}
+	return value != nil && t.Format("2006-01-02 15:04") == value.Format("2006-01-02 15:04")
+}
+
+// parsePlanningTimestamp parses a planning timestamp the way the parser reads it:
+// inactive for CLOSED and active otherwise
+func parsePlanningTimestamp(keyword, text string) (time.Time, bool) {
+	if keyword == "CLOSED" {
+		if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+			return time.Time{}, false
+		}
+		t, err := parseClockTimestamp(text[1 : len(text)-1])
+		return t, err == nil
+	}
+	if !strings.HasPrefix(text, "<") || !strings.HasSuffix(text, ">") {
+		return time.Time{}, false
+	}
+	t, err := parseOrgDate(text[1 : len(text)-1])
+	return t, err == nil
+}
+
+// formatPlanningTimestamp formats a planning timestamp: inactive with a time for
+// CLOSED, active with a time only if it has one otherwise
+func formatPlanningTimestamp(keyword string, t time.Time) string {
+	if keyword == "CLOSED" {
+		return "[" + formatClockTimestamp(t) + "]"
+	}
+	if t.Hour() != 0 || t.Minute() != 0 {
+		return "<" + formatClockTimestamp(t) + ">"
+	}
+	return "<" + FormatOrgDate(t) + ">"
+}
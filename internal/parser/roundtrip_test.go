@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwejlgaard/org/internal/config"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// roundTrip parses an org file and saves it to a temporary file, returning the
+// parsed file and the text written
+func roundTrip(t *testing.T, path string, change func(*model.OrgFile)) (*model.OrgFile, string) {
+	t.Helper()
+	orgFile, err := ParseOrgFile(path, config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	if change != nil {
+		change(orgFile)
+	}
+
+	orgFile.Path = filepath.Join(t.TempDir(), filepath.Base(path))
+	if err := Save(orgFile); err != nil {
+		t.Fatalf("saving %s: %v", path, err)
+	}
+	written, err := os.ReadFile(orgFile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orgFile, string(written)
+}
+
+// writeOrg writes text to an org file in a temporary directory
+func writeOrg(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.org")
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// diffLine describes the first line where two texts differ
+func diffLine(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want %q\n  got  %q", i+1, w, g)
+		}
+	}
+	return "no differing line"
+}
+
+func TestRoundTripCorpus(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.org"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no files in testdata/roundtrip")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			original, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, written := roundTrip(t, path, nil)
+			if written != string(original) {
+				t.Errorf("file changed on a round trip, first difference at %s", diffLine(string(original), written))
+			}
+		})
+	}
+}
+
+func TestChangedPlanningLine(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		"  DEADLINE: <2024-03-01 Fri 17:00 -2d> SCHEDULED: <2024-02-26 Mon>\n"+
+		"  Body\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		item := orgFile.Items[0]
+		scheduled := time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC)
+		item.Scheduled = &scheduled
+		closed := time.Date(2024, 2, 28, 9, 30, 0, 0, time.UTC)
+		item.Closed = &closed
+	})
+
+	want := "* TODO Task\n" +
+		"  CLOSED: [2024-02-28 Wed 09:30] DEADLINE: <2024-03-01 Fri 17:00 -2d> SCHEDULED: <2024-02-27 Tue>\n" +
+		"  Body\n"
+	if written != want {
+		t.Errorf("changed planning line, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestClearedPlanningEntry(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\nSCHEDULED: <2024-02-26 Mon> DEADLINE: <2024-03-01 Fri>\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		orgFile.Items[0].Scheduled = nil
+	})
+
+	want := "* TODO Task\nDEADLINE: <2024-03-01 Fri>\n"
+	if written != want {
+		t.Errorf("cleared planning entry, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestClockInKeepsPlanningLine(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n"+
+		"SCHEDULED: <2024-02-26 Mon>  DEADLINE: <2024-03-01 Fri>\n"+
+		"Body\n")
+
+	orgFile, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		orgFile.Items[0].ClockIn()
+	})
+
+	lines := strings.Split(written, "\n")
+	if want := "SCHEDULED: <2024-02-26 Mon>  DEADLINE: <2024-03-01 Fri>"; lines[1] != want {
+		t.Errorf("planning line written as %q, want %q", lines[1], want)
+	}
+	if !orgFile.Items[0].IsClockedIn() || lines[2] != ":LOGBOOK:" {
+		t.Errorf("clock entry not written:\n%s", written)
+	}
+}
+
+func TestNewPlanningLine(t *testing.T) {
+	path := writeOrg(t, "* TODO Task\n:PROPERTIES:\n:ID: abc\n:END:\nBody\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		deadline := time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC)
+		orgFile.Items[0].Deadline = &deadline
+	})
+
+	want := "* TODO Task\nDEADLINE: <2024-03-01 Fri 14:00>\n:PROPERTIES:\n:ID: abc\n:END:\nBody\n"
+	if written != want {
+		t.Errorf("new planning line, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestChangedHeading(t *testing.T) {
+	path := writeOrg(t, "* TODO Task [1/2]                                                 :work:\n"+
+		"*  Loose   spacing\n")
+
+	_, written := roundTrip(t, path, func(orgFile *model.OrgFile) {
+		orgFile.Items[0].State = model.StateDONE
+		orgFile.Items[1].Priority = model.PriorityA
+	})
+
+	want := "* DONE Task [1/2]                                                 :work:\n" +
+		"* [#A] Loose   spacing\n"
+	if written != want {
+		t.Errorf("changed heading, first difference at %s", diffLine(want, written))
+	}
+}
+
+func TestSaveSkipsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, text := range map[string]string{"a.org": "* TODO A\n", "b.org": "* TODO B\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orgFile, err := ParseMultipleOrgFiles(dir, config.DefaultConfig(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b.org is edited elsewhere while only a.org is changed here
+	edited := "* TODO B, edited elsewhere\n"
+	if err := os.WriteFile(filepath.Join(dir, "b.org"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range model.FlattenAllItems(orgFile.Items) {
+		if item.Title == "A" {
+			item.State = model.StateDONE
+		}
+	}
+	if err := Save(orgFile); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"a.org": "* DONE A\n", "b.org": edited} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,99 +2,165 @@ package parser
 
 import (
 	"bufio"
-	"fmt"
+	"bytes"
+	"crypto/sha256"
 	"os"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/rwejlgaard/org/internal/model"
 )
 
-// Save writes the org file back to disk
+// alignedTagsPattern matches tags set apart from the title by more than one space
+var alignedTagsPattern = regexp.MustCompile(`\S\s{2,}:[[:alnum:]_@#%:]+:\s*$`)
+
+// Save writes the org file back to disk. Files whose text is the same as when
+// they were last read or written are left alone, so files that weren't changed
+// keep their modification times and any edits made to them elsewhere.
 func Save(orgFile *model.OrgFile) error {
-	// Check if this is a multi-file org (directory-based)
-	// In multi-file mode, top-level items have SourceFile set and represent files
-	isMultiFile := false
-	if len(orgFile.Items) > 0 && orgFile.Items[0].SourceFile != "" {
-		isMultiFile = true
+	files, err := formatFiles(orgFile)
+	if err != nil {
+		return err
 	}
-
-	if isMultiFile {
-		return saveMultiFile(orgFile)
+	for _, file := range files {
+		if err := saveFileText(orgFile, file); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Single file mode
-	file, err := os.Create(orgFile.Path)
+// SaveFile writes the file of one file item of a multi-file org file, if it
+// changed since it was last read or written
+func SaveFile(orgFile *model.OrgFile, fileItem *model.Item) error {
+	text, err := formatFile(fileItem.Notes, fileItem.Children, fileItem.Level)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	return saveFileText(orgFile, fileText{path: fileItem.SourceFile, text: text})
+}
 
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+// saveFileText writes the text of a file unless it is the text last read or
+// written, noting it as saved
+func saveFileText(orgFile *model.OrgFile, file fileText) error {
+	if orgFile.Saved == nil {
+		orgFile.Saved = make(map[string][32]byte)
+	}
+	sum := sha256.Sum256(file.text)
+	if saved, ok := orgFile.Saved[file.path]; ok && saved == sum {
+		return nil
+	}
+	if err := os.WriteFile(file.path, file.text, 0666); err != nil {
+		return err
+	}
+	orgFile.Saved[file.path] = sum
+	return nil
+}
 
-	for _, item := range orgFile.Items {
-		if err := writeItem(writer, item); err != nil {
-			return err
+// markSaved notes the text of each file of a newly read org file, for Save to
+// skip the files that are left unchanged. Files that don't exist yet aren't
+// noted, so they are created on saving.
+func markSaved(orgFile *model.OrgFile) error {
+	files, err := formatFiles(orgFile)
+	if err != nil {
+		return err
+	}
+	orgFile.Saved = make(map[string][32]byte)
+	for _, file := range files {
+		if _, err := os.Stat(file.path); err == nil {
+			orgFile.Saved[file.path] = sha256.Sum256(file.text)
 		}
 	}
-
 	return nil
 }
 
-// saveMultiFile saves items back to their individual source files
-func saveMultiFile(orgFile *model.OrgFile) error {
-	// Group items by source file
-	fileItems := make(map[string][]*model.Item)
+// fileText is the text of a file as it is written
+type fileText struct {
+	path string
+	text []byte
+}
 
-	for _, fileItem := range orgFile.Items {
-		if fileItem.SourceFile == "" {
-			continue
+// formatFiles formats the text of each file of an org file: the file itself, or
+// in multi-file mode the files whose items are below the top-level items
+func formatFiles(orgFile *model.OrgFile) ([]fileText, error) {
+	// Check if this is a multi-file org (directory-based)
+	// In multi-file mode, top-level items represent files and directories
+	if !orgFile.IsMultiFile() {
+		text, err := formatFile(orgFile.Preamble, orgFile.Items, 0)
+		if err != nil {
+			return nil, err
 		}
-
-		// The children of this file item are the actual items to save
-		fileItems[fileItem.SourceFile] = fileItem.Children
+		return []fileText{{path: orgFile.Path, text: text}}, nil
 	}
 
-	// Save each file
-	for filePath, items := range fileItems {
-		if err := saveItemsToFile(filePath, items); err != nil {
-			return err
+	var files []fileText
+	for _, fileItem := range model.FileItems(orgFile.Items) {
+		// The children of this file item are the actual items to save,
+		// and its notes are the file's preamble
+		text, err := formatFile(fileItem.Notes, fileItem.Children, fileItem.Level)
+		if err != nil {
+			return nil, err
 		}
+		files = append(files, fileText{path: fileItem.SourceFile, text: text})
 	}
-
-	return nil
+	return files, nil
 }
 
-// saveItemsToFile writes a list of items to a specific file
-func saveItemsToFile(filePath string, items []*model.Item) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+// formatFile formats a preamble and a list of items as the text of a file, with
+// the items moved up by depth levels
+func formatFile(preamble []string, items []*model.Item, depth int) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
 
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	if err := writePreamble(writer, preamble); err != nil {
+		return nil, err
+	}
 
 	for _, item := range items {
-		// Decrement level since we're saving to individual files
-		decrementedItem := decrementItemLevelForSave(item)
-		if err := writeItem(writer, decrementedItem); err != nil {
-			return err
+		// Remove the levels of the wrappers since we're saving to individual files
+		if depth != 0 {
+			item = shiftItemLevelForSave(item, -depth)
 		}
+		if err := writeItem(writer, item); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
+// writePreamble writes the lines that precede the first heading
+func writePreamble(writer *bufio.Writer, preamble []string) error {
+	for _, line := range preamble {
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// decrementItemLevelForSave creates a copy of an item with decremented levels for saving
-func decrementItemLevelForSave(item *model.Item) *model.Item {
+// SaveSubtree writes an item and its children to a file on their own, with the
+// item as a top-level heading
+func SaveSubtree(filePath string, item *model.Item) error {
+	text, err := formatFile(nil, []*model.Item{item}, item.Level-1)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, text, 0666)
+}
+
+// shiftItemLevelForSave creates a copy of an item with its levels shifted by delta
+func shiftItemLevelForSave(item *model.Item, delta int) *model.Item {
 	copied := *item
-	copied.Level--
+	copied.Level += delta
 
 	copiedChildren := make([]*model.Item, len(item.Children))
 	for i, child := range item.Children {
-		copiedChildren[i] = decrementItemLevelForSave(child)
+		copiedChildren[i] = shiftItemLevelForSave(child, delta)
 	}
 	copied.Children = copiedChildren
 
@@ -104,102 +170,14 @@ func decrementItemLevelForSave(item *model.Item) *model.Item {
 // writeItem recursively writes an item and its children
 func writeItem(writer *bufio.Writer, item *model.Item) error {
 	// Write heading
-	stars := strings.Repeat("*", item.Level)
-	line := stars
-	if item.State != model.StateNone {
-		line += " " + string(item.State)
-	}
-	if item.Priority != model.PriorityNone {
-		line += " [#" + string(item.Priority) + "]"
-	}
-	line += " " + item.Title
-
-	// Add tags if present
-	if len(item.Tags) > 0 {
-		line += " :" + strings.Join(item.Tags, ":") + ":"
-	}
-
-	line += "\n"
-
+	line := strings.Repeat("*", item.Level) + headingRest(item) + "\n"
 	if _, err := writer.WriteString(line); err != nil {
 		return err
 	}
 
-	// Write scheduling info if not already in notes
-	hasScheduled := false
-	hasDeadline := false
-	hasClosed := false
-	hasLogbook := false
-	hasProperties := false
-	for _, note := range item.Notes {
-		if strings.Contains(note, "SCHEDULED:") {
-			hasScheduled = true
-		}
-		if strings.Contains(note, "DEADLINE:") {
-			hasDeadline = true
-		}
-		if strings.Contains(note, "CLOSED:") {
-			hasClosed = true
-		}
-		if strings.Contains(note, ":LOGBOOK:") {
-			hasLogbook = true
-		}
-		if strings.Contains(note, ":PROPERTIES:") {
-			hasProperties = true
-		}
-	}
-
-	if item.Closed != nil && !hasClosed {
-		closedLine := fmt.Sprintf("CLOSED: [%s]\n", formatClockTimestamp(*item.Closed))
-		if _, err := writer.WriteString(closedLine); err != nil {
-			return err
-		}
-	}
-
-	if item.Scheduled != nil && !hasScheduled {
-		scheduledLine := fmt.Sprintf("SCHEDULED: <%s>\n", FormatOrgDate(*item.Scheduled))
-		if _, err := writer.WriteString(scheduledLine); err != nil {
-			return err
-		}
-	}
-
-	if item.Deadline != nil && !hasDeadline {
-		deadlineLine := fmt.Sprintf("DEADLINE: <%s>\n", FormatOrgDate(*item.Deadline))
-		if _, err := writer.WriteString(deadlineLine); err != nil {
-			return err
-		}
-	}
-
-	// Write effort in :PROPERTIES: drawer if not already in notes
-	if item.Effort != "" && !hasProperties {
-		if _, err := writer.WriteString(":PROPERTIES:\n"); err != nil {
-			return err
-		}
-		effortLine := fmt.Sprintf(":EFFORT: %s\n", item.Effort)
-		if _, err := writer.WriteString(effortLine); err != nil {
-			return err
-		}
-		if _, err := writer.WriteString(":END:\n"); err != nil {
-			return err
-		}
-	}
-
-	// Write clock entries in :LOGBOOK: drawer if not already in notes
-	if len(item.ClockEntries) > 0 && !hasLogbook {
-		if _, err := writer.WriteString(":LOGBOOK:\n"); err != nil {
-			return err
-		}
-		for _, entry := range item.ClockEntries {
-			clockLine := fmt.Sprintf("CLOCK: [%s]", formatClockTimestamp(entry.Start))
-			if entry.End != nil {
-				clockLine += fmt.Sprintf("--[%s]", formatClockTimestamp(*entry.End))
-			}
-			clockLine += "\n"
-			if _, err := writer.WriteString(clockLine); err != nil {
-				return err
-			}
-		}
-		if _, err := writer.WriteString(":END:\n"); err != nil {
+	// Write the planning line and drawers
+	for _, meta := range metadataLines(item) {
+		if _, err := writer.WriteString(meta + "\n"); err != nil {
 			return err
 		}
 	}
@@ -220,3 +198,50 @@ func writeItem(writer *bufio.Writer, item *model.Item) error {
 
 	return nil
 }
+
+// headingRest returns the part of an item's heading line after the stars. The
+// line as read is kept while the fields it was parsed into are unchanged.
+func headingRest(item *model.Item) string {
+	rest := formatHeadingRest(item)
+	if item.RawHeading.Line == "" {
+		return rest
+	}
+	if rest == item.RawHeading.Formatted {
+		return item.RawHeading.Line
+	}
+	return alignTags(rest, item)
+}
+
+// formatHeadingRest formats the part of an item's heading line after the stars
+func formatHeadingRest(item *model.Item) string {
+	rest := ""
+	if item.State != model.StateNone {
+		rest += " " + string(item.State)
+	}
+	if item.Priority != model.PriorityNone {
+		rest += " [#" + string(item.Priority) + "]"
+	}
+	rest += " " + item.Title
+
+	// Add tags if present
+	if len(item.Tags) > 0 {
+		rest += " :" + strings.Join(item.Tags, ":") + ":"
+	}
+	return rest
+}
+
+// alignTags pads the tags of a changed heading so they end in the same column as
+// they did in the line as read, if they were aligned there
+func alignTags(rest string, item *model.Item) string {
+	if len(item.Tags) == 0 || !alignedTagsPattern.MatchString(item.RawHeading.Line) {
+		return rest
+	}
+	tags := ":" + strings.Join(item.Tags, ":") + ":"
+	head := strings.TrimSuffix(rest, " "+tags)
+	width := utf8.RuneCountInString(strings.TrimRight(item.RawHeading.Line, " \t"))
+	padding := width - utf8.RuneCountInString(head) - utf8.RuneCountInString(tags)
+	if padding < 1 {
+		padding = 1
+	}
+	return head + strings.Repeat(" ", padding) + tags
+}
@@ -6,7 +6,8 @@ import (
 	"github.com/rwejlgaard/org/internal/model"
 )
 
-// getAgendaItems returns items with scheduling or deadlines within the next 7 days
+// getAgendaItems returns items with scheduling or deadlines within the next 7 days,
+// from the edited file and the other agenda files
 func (m uiModel) getAgendaItems() []*model.Item {
 	var items []*model.Item
 	now := time.Now()
@@ -27,6 +28,9 @@ func (m uiModel) getAgendaItems() []*model.Item {
 		}
 	}
 	getAllItems(m.orgFile.Items)
+	if m.agendaFiles != nil {
+		getAllItems(m.agendaFiles.Items)
+	}
 
 	return items
 }
@@ -29,10 +29,24 @@ const (
 	modeSettings
 	modeTagEdit
 	modeRename
+	modeFilter
+	modeSort
+	modeEffortReport
+	modeColumns
+	modeLinkSelect
+	modeInsertLink
+	modeTableEdit
+	modeConfirmExecute
+	modeNewFile
+	modeMoveToFile
+	modeResolveClock
+	modeClockIdle
+	modeClockHistory
 )
 
 type uiModel struct {
 	orgFile         *model.OrgFile
+	agendaFiles     *model.OrgFile // Agenda files shown in the agenda view but not in the list (nil if none)
 	cursor          int
 	scrollOffset    int // Track the scroll position
 	helpScroll      int // Track scroll position in help mode
@@ -50,13 +64,48 @@ type uiModel struct {
 	textinput       textinput.Model
 	itemToDelete    *model.Item
 	reorderMode     bool
-	settingsCursor  int             // Cursor position in settings view
-	settingsScroll  int             // Scroll position in settings view
-	settingsSection settingsSection // Current settings section/tab
-	captureCursor   int             // Store cursor position when entering capture mode
+	settingsCursor  int                  // Cursor position in settings view
+	settingsScroll  int                  // Scroll position in settings view
+	settingsSection settingsSection      // Current settings section/tab
+	captureCursor   int                  // Store cursor position when entering capture mode
+	marked          map[*model.Item]bool // Items marked for bulk operations
+	visualMode      bool                 // Whether a visual range selection is active
+	visualAnchor    int                  // Cursor position where the visual selection started
+	bulkItems       []*model.Item        // Items targeted by the current prompt when acting in bulk
+	filter          *itemFilter          // Active list filter (nil if none)
+	narrowRoot      *model.Item          // Item the view is narrowed to (nil if not narrowed)
+	sortKeys        []sortKey            // Sort keys chosen so far in the sort dialog
+	sortTopLevel    bool                 // Whether the sort dialog targets the top-level items
+	reportScroll    int                  // Scroll position in report views
+	columns         []columnDef          // Columns shown in the column view
+	columnCursor    int                  // Selected column in the column view
+	columnEditing   bool                 // Whether a column view cell is being edited
+	links           []orgLink            // Links offered by the link picker
+	linkCursor      int                  // Selected link in the link picker
+	table           *model.Table         // Table being edited
+	tableIndex      int                  // Index of the edited table among the item's tables
+	tableCell       tableCell            // Cell being edited
+	tableIsNew      bool                 // Whether the edited table is not yet in the notes
+	srcBlocks       []model.SrcBlock     // Source blocks offered for execution
+	srcBlockCursor  int                  // Selected source block
+	fileTargets     []*model.Item        // Files offered by the move to file picker
+	fileCursor      int                  // Selected file in the move to file picker
+	danglingClocks  []*model.Item        // Items with clocks left running by a previous session, still to be resolved
+	clockTimeInput  bool                 // Whether the time to stop a dangling clock at is being entered
+	clockReturnMode viewMode             // Mode to return to from the clock dialogs
+	lastActivity    time.Time            // Time of the last key press, for idle detection
+	idleItem        *model.Item          // Item clocked in to while idle
+	idleStart       time.Time            // Time the idle period began
+	clockHistory    []*model.Item        // Recently clocked items offered by the clock history
+	historyCursor   int                  // Selected item in the clock history
+	pomodoroItem    *model.Item          // Item of the running pomodoro (nil if none)
+	pomodoroEnd     time.Time            // Time the running pomodoro or its break ends
+	pomodoroBreak   bool                 // Whether the pomodoro is over and its break is running
 }
 
-func InitialModel(orgFile *model.OrgFile, cfg *config.Config, captureMode bool, captureText string) uiModel {
+// InitialModel creates the UI for editing orgFile. The agenda view also shows the
+// items of agendaFiles, which may be nil, and agendaMode starts in the agenda view.
+func InitialModel(orgFile, agendaFiles *model.OrgFile, cfg *config.Config, agendaMode, captureMode bool, captureText string) uiModel {
 	ta := textarea.New()
 	ta.Placeholder = "Enter notes here (code blocks supported)..."
 	ta.ShowLineNumbers = false
@@ -69,29 +118,40 @@ func InitialModel(orgFile *model.OrgFile, cfg *config.Config, captureMode bool,
 	h.ShowAll = false
 
 	mode := modeList
+	if agendaMode {
+		mode = modeAgenda
+	}
 	if captureMode {
 		mode = modeCapture
 		ti.SetValue(strings.TrimSpace(captureText))
 	}
 
-	return uiModel{
-		orgFile:   orgFile,
-		cursor:    0,
-		mode:      mode,
-		help:      h,
-		keys:      newKeyMapFromConfig(cfg),
-		styles:    newStyleMapFromConfig(cfg),
-		config:    cfg,
-		textarea:  ta,
-		textinput: ti,
+	m := uiModel{
+		orgFile:      orgFile,
+		agendaFiles:  agendaFiles,
+		cursor:       0,
+		mode:         mode,
+		help:         h,
+		keys:         newKeyMapFromConfig(cfg),
+		styles:       newStyleMapFromConfig(cfg),
+		config:       cfg,
+		textarea:     ta,
+		textinput:    ti,
+		marked:       make(map[*model.Item]bool),
+		lastActivity: time.Now(),
+	}
+	// Clocks still running were left by a session that didn't clock out
+	if !captureMode {
+		m.startClockResolution(mode)
 	}
+	return m
 }
 
 func (m uiModel) Init() tea.Cmd {
 	if m.mode == modeCapture {
-		return textinput.Blink
+		return tea.Batch(textinput.Blink, clockTick())
 	}
-	return nil
+	return clockTick()
 }
 
 func (m *uiModel) setStatus(msg string) {
@@ -101,9 +161,27 @@ func (m *uiModel) setStatus(msg string) {
 
 func (m uiModel) getVisibleItems() []*model.Item {
 	if m.mode == modeAgenda {
-		return m.getAgendaItems()
+		items := m.getAgendaItems()
+		if m.filter == nil {
+			return items
+		}
+		var filtered []*model.Item
+		for _, item := range items {
+			if m.filter.matches(item) {
+				filtered = append(filtered, item)
+			}
+		}
+		return filtered
+	}
+
+	roots := m.orgFile.Items
+	if m.narrowRoot != nil {
+		roots = []*model.Item{m.narrowRoot}
+	}
+	if m.filter != nil {
+		return m.filter.apply(roots)
 	}
-	return m.orgFile.GetAllItems()
+	return model.FlattenItems(roots)
 }
 
 func (m *uiModel) updateScrollOffset(availableHeight int) {
@@ -150,8 +228,8 @@ func (m *uiModel) updateScrollOffset(availableHeight int) {
 }
 
 // RunUI starts the terminal UI
-func RunUI(orgFile *model.OrgFile, cfg *config.Config, captureMode bool, captureText string) error {
-	m := InitialModel(orgFile, cfg, captureMode, captureText)
+func RunUI(orgFile, agendaFiles *model.OrgFile, cfg *config.Config, agendaMode, captureMode bool, captureText string) error {
+	m := InitialModel(orgFile, agendaFiles, cfg, agendaMode, captureMode, captureText)
 	if captureMode {
 		m.textinput.Focus()
 	}
@@ -0,0 +1,256 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// srcBlockResultMsg reports the output of a source block run in the background
+type srcBlockResultMsg struct {
+	item     *model.Item
+	block    model.SrcBlock
+	output   string
+	err      error
+	timedOut bool
+}
+
+// srcFileExtensions maps source block languages to the file extension their
+// interpreter expects (go run only accepts .go files)
+var srcFileExtensions = map[string]string{
+	"sh":         ".sh",
+	"bash":       ".sh",
+	"zsh":        ".sh",
+	"python":     ".py",
+	"go":         ".go",
+	"ruby":       ".rb",
+	"perl":       ".pl",
+	"js":         ".js",
+	"javascript": ".js",
+}
+
+// maxResultLines limits how much output is inserted into the notes
+const maxResultLines = 200
+
+// startExecuteBlock asks for confirmation to run a source block of the item under
+// the cursor. Only blocks in a language with a configured interpreter are offered.
+func (m *uiModel) startExecuteBlock() {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return
+	}
+	item := items[m.cursor]
+
+	blocks := model.FindSrcBlocks(item.Notes)
+	if len(blocks) == 0 {
+		m.setStatus("No source blocks in this item")
+		return
+	}
+
+	var runnable []model.SrcBlock
+	for _, block := range blocks {
+		if _, ok := m.config.Babel.Interpreters[block.Language]; ok {
+			runnable = append(runnable, block)
+		}
+	}
+	if len(runnable) == 0 {
+		language := blocks[0].Language
+		if language == "" {
+			language = "blocks without a language"
+		}
+		m.setStatus(fmt.Sprintf("No interpreter configured for %s", language))
+		return
+	}
+
+	m.editingItem = item
+	m.srcBlocks = runnable
+	m.srcBlockCursor = 0
+	m.mode = modeConfirmExecute
+}
+
+// itemFilePath returns the path of the file an item belongs to
+func (m uiModel) itemFilePath(item *model.Item) string {
+	if item.SourceFile != "" {
+		return item.SourceFile
+	}
+	return m.orgFile.Path
+}
+
+// runSrcBlock runs a source block with its language's interpreter in the
+// directory of the item's file. The code is written to a temporary file that is
+// passed to the interpreter, and the run is stopped after the configured timeout.
+func (m uiModel) runSrcBlock(item *model.Item, block model.SrcBlock) tea.Cmd {
+	command := strings.Fields(m.config.Babel.Interpreters[block.Language])
+	timeout := time.Duration(m.config.Babel.Timeout) * time.Second
+	dir := ""
+	if path := m.itemFilePath(item); path != "" {
+		dir = filepath.Dir(path)
+	}
+	code := strings.Join(model.Dedent(block.Code), "\n") + "\n"
+
+	return func() tea.Msg {
+		msg := srcBlockResultMsg{item: item, block: block}
+		if len(command) == 0 {
+			msg.err = fmt.Errorf("empty interpreter command for %s", block.Language)
+			return msg
+		}
+
+		tmpDir, err := os.MkdirTemp("", "org-src-")
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		defer os.RemoveAll(tmpDir)
+
+		file := filepath.Join(tmpDir, "block"+srcFileExtensions[block.Language])
+		if err := os.WriteFile(file, []byte(code), 0600); err != nil {
+			msg.err = err
+			return msg
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, command[0], append(command[1:], file)...)
+		cmd.Dir = dir
+		// Don't wait for the output of processes the block left running
+		cmd.WaitDelay = time.Second
+		output, err := cmd.CombinedOutput()
+		msg.output = string(output)
+		if ctx.Err() == context.DeadlineExceeded {
+			msg.timedOut = true
+		} else {
+			msg.err = err
+		}
+		return msg
+	}
+}
+
+// applySrcBlockResult inserts a finished block's output into the notes as its results
+func (m *uiModel) applySrcBlockResult(msg srcBlockResultMsg) {
+	if msg.timedOut {
+		m.setStatus(fmt.Sprintf("Source block timed out after %ds", m.config.Babel.Timeout))
+		return
+	}
+	var exitErr *exec.ExitError
+	if msg.err != nil && !errors.As(msg.err, &exitErr) {
+		m.setStatus(fmt.Sprintf("Failed to run source block: %v", msg.err))
+		return
+	}
+
+	// The notes may have been edited while the block ran, so find it again
+	var target *model.SrcBlock
+	for _, block := range model.FindSrcBlocks(msg.item.Notes) {
+		if strings.Join(block.Code, "\n") == strings.Join(msg.block.Code, "\n") {
+			found := block
+			target = &found
+			if block.Start == msg.block.Start {
+				break
+			}
+		}
+	}
+	if target == nil {
+		m.setStatus("Source block changed while running, results discarded")
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(msg.output, "\n"), "\n")
+	if len(lines) > maxResultLines {
+		lines = append(lines[:maxResultLines], fmt.Sprintf("... (%d more lines)", len(lines)-maxResultLines))
+	}
+	msg.item.Notes = model.SetSrcBlockResults(msg.item.Notes, *target, strings.Join(lines, "\n"))
+
+	if exitErr != nil {
+		m.setStatus(fmt.Sprintf("Source block exited with code %d", exitErr.ExitCode()))
+	} else {
+		m.setStatus("Source block finished")
+	}
+}
+
+// updateConfirmExecute handles the confirmation before running a source block
+func (m uiModel) updateConfirmExecute(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			block := m.srcBlocks[m.srcBlockCursor]
+			item := m.editingItem
+			m.mode = modeList
+			m.srcBlocks = nil
+			m.editingItem = nil
+			m.setStatus(fmt.Sprintf("Running %s block...", block.Language))
+			return m, m.runSrcBlock(item, block)
+		case "tab", "down", "j":
+			m.srcBlockCursor = (m.srcBlockCursor + 1) % len(m.srcBlocks)
+		case "shift+tab", "up", "k":
+			m.srcBlockCursor = (m.srcBlockCursor + len(m.srcBlocks) - 1) % len(m.srcBlocks)
+		case "n", "N", "esc":
+			m.mode = modeList
+			m.srcBlocks = nil
+			m.editingItem = nil
+			m.setStatus("Cancelled")
+		}
+	}
+	return m, nil
+}
+
+// viewConfirmExecute renders the confirmation dialog for running a source block
+func (m uiModel) viewConfirmExecute() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(60)
+
+	block := m.srcBlocks[m.srcBlockCursor]
+
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Run Source Block"))
+	content.WriteString("\n\n")
+	header := fmt.Sprintf("%s block", block.Language)
+	if len(m.srcBlocks) > 1 {
+		header = fmt.Sprintf("%s block %d of %d", block.Language, m.srcBlockCursor+1, len(m.srcBlocks))
+	}
+	content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).Render(header))
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("Command: " + m.config.Babel.Interpreters[block.Language]))
+	content.WriteString("\n\n")
+
+	// Preview the start of the code
+	code := model.Dedent(block.Code)
+	const previewLines = 8
+	preview := code
+	if len(preview) > previewLines {
+		preview = preview[:previewLines]
+	}
+	content.WriteString(highlightCode(strings.Join(preview, "\n"), block.Language))
+	content.WriteString("\n")
+	if len(code) > previewLines {
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("... and %d more lines", len(code)-previewLines)))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	if len(m.srcBlocks) > 1 {
+		content.WriteString("Press Y to run • Tab for next block • N or ESC to cancel")
+	} else {
+		content.WriteString("Press Y to run • N or ESC to cancel")
+	}
+
+	dialog := dialogStyle.Render(content.String())
+
+	// Center the dialog horizontally and vertically
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
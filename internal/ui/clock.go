@@ -0,0 +1,486 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// clockedItems returns the items with a running clock, in the edited files and
+// the other agenda files
+func (m uiModel) clockedItems() []*model.Item {
+	all := model.FlattenAllItems(m.orgFile.Items)
+	if m.agendaFiles != nil {
+		all = append(all, model.FlattenAllItems(m.agendaFiles.Items)...)
+	}
+
+	var clocked []*model.Item
+	for _, item := range all {
+		if item.IsClockedIn() {
+			clocked = append(clocked, item)
+		}
+	}
+	return clocked
+}
+
+// runningClock returns the item the clock is running on, or nil
+func (m uiModel) runningClock() *model.Item {
+	if clocked := m.clockedItems(); len(clocked) > 0 {
+		return clocked[0]
+	}
+	return nil
+}
+
+// clockIn starts the clock on an item. Only one clock runs at a time, so a clock
+// running on another item is stopped first. It returns the item clocked out of,
+// or nil.
+func (m *uiModel) clockIn(item *model.Item) *model.Item {
+	var previous *model.Item
+	for _, clocked := range m.clockedItems() {
+		if clocked != item {
+			clocked.ClockOut()
+			previous = clocked
+		}
+	}
+	item.ClockIn()
+	return previous
+}
+
+// startClockResolution shows the dialog for clocks left running by a previous
+// session, if there are any, returning to mode once they are resolved
+func (m *uiModel) startClockResolution(mode viewMode) {
+	m.danglingClocks = m.clockedItems()
+	if len(m.danglingClocks) == 0 {
+		return
+	}
+	m.clockReturnMode = mode
+	m.mode = modeResolveClock
+}
+
+// finishClockResolution moves on to the next dangling clock, or back to the
+// view the dialog was shown over
+func (m *uiModel) finishClockResolution(status string) {
+	m.danglingClocks = m.danglingClocks[1:]
+	m.clockTimeInput = false
+	m.textinput.Blur()
+	m.setStatus(status)
+	if len(m.danglingClocks) == 0 {
+		m.mode = m.clockReturnMode
+	}
+}
+
+func (m uiModel) updateResolveClock(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	item := m.danglingClocks[0]
+	entry := item.OpenClockEntry()
+	if entry == nil {
+		// The clock was resolved some other way
+		m.finishClockResolution("")
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		if m.clockTimeInput {
+			switch msg.Type {
+			case tea.KeyEnter:
+				end, err := parseClockEndTime(m.textinput.Value(), entry.Start, time.Now())
+				if err != nil {
+					m.setStatus(fmt.Sprintf("Invalid time: %v", err))
+					return m, nil
+				}
+				item.ClockOutAt(end)
+				m.finishClockResolution(fmt.Sprintf("Clock on %s stopped at %s", item.Title, end.Format("2006-01-02 15:04")))
+				return m, nil
+			case tea.KeyEsc:
+				m.clockTimeInput = false
+				m.textinput.Blur()
+				return m, nil
+			}
+			m.textinput, cmd = m.textinput.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "k", "K", "esc":
+			// Only one clock keeps running, so one kept before is stopped
+			for _, other := range m.clockedItems() {
+				if other != item && !isDangling(m.danglingClocks, other) {
+					other.ClockOut()
+				}
+			}
+			m.finishClockResolution(fmt.Sprintf("Clock on %s kept running", item.Title))
+		case "c", "C":
+			m.clockTimeInput = true
+			m.textinput.SetValue("")
+			m.textinput.Placeholder = "HH:MM or YYYY-MM-DD HH:MM"
+			m.textinput.Focus()
+			return m, textinput.Blink
+		case "x", "X":
+			item.CancelClock()
+			m.finishClockResolution(fmt.Sprintf("Clock on %s discarded", item.Title))
+		}
+	}
+	return m, nil
+}
+
+// isDangling returns true if an item's clock is still to be resolved
+func isDangling(dangling []*model.Item, item *model.Item) bool {
+	for _, d := range dangling {
+		if d == item {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClockEndTime parses the time a clock is stopped at: a time of day on the
+// day the clock started (or the day after, if that is earlier than the start),
+// or a full date and time. It must lie between the start and now.
+func parseClockEndTime(input string, start, now time.Time) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	var end time.Time
+	if t, err := time.ParseInLocation("2006-01-02 15:04", input, start.Location()); err == nil {
+		end = t
+	} else if t, err := time.ParseInLocation("15:04", input, start.Location()); err == nil {
+		end = time.Date(start.Year(), start.Month(), start.Day(), t.Hour(), t.Minute(), 0, 0, start.Location())
+		if end.Before(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+	} else {
+		return time.Time{}, fmt.Errorf("use HH:MM or YYYY-MM-DD HH:MM")
+	}
+
+	if end.Before(start) {
+		return time.Time{}, fmt.Errorf("the clock started at %s", start.Format("2006-01-02 15:04"))
+	}
+	if end.After(now) {
+		return time.Time{}, fmt.Errorf("%s is in the future", end.Format("2006-01-02 15:04"))
+	}
+	return end, nil
+}
+
+func (m uiModel) viewResolveClock() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(60)
+
+	item := m.danglingClocks[0]
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Clock Left Running"))
+	content.WriteString("\n\n")
+	content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).Render(item.Title))
+	content.WriteString("\n")
+	if entry := item.OpenClockEntry(); entry != nil {
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Clocked in since %s (%s ago)",
+			entry.Start.Format("2006-01-02 15:04"), formatDuration(time.Since(entry.Start)))))
+		content.WriteString("\n")
+	}
+	if len(m.danglingClocks) > 1 {
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("%d more clocks to resolve", len(m.danglingClocks)-1)))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
+	if m.clockTimeInput {
+		content.WriteString("Stop the clock at:\n")
+		content.WriteString(m.textinput.View())
+		content.WriteString("\n\n")
+		content.WriteString("Press Enter to stop the clock • ESC to go back")
+	} else {
+		content.WriteString("K keep it running • C stop it at a given time • X discard it")
+	}
+
+	dialog := dialogStyle.Render(content.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// noteActivity records a key press. If it comes after the idle time set in the
+// config while a clock is running outside a pomodoro, it notes when the idle
+// time began and returns true, for startClockIdle to ask about it.
+func (m *uiModel) noteActivity(now time.Time) bool {
+	last := m.lastActivity
+	m.lastActivity = now
+	idle := time.Duration(m.config.Clock.IdleMinutes) * time.Minute
+	if idle <= 0 || last.IsZero() || now.Sub(last) < idle {
+		return false
+	}
+	// A pomodoro is focused work, even away from the keyboard
+	if m.pomodoroItem != nil || m.mode == modeClockIdle || m.mode == modeResolveClock {
+		return false
+	}
+
+	item := m.runningClock()
+	if item == nil {
+		return false
+	}
+	idleStart := last
+	if start := item.OpenClockEntry().Start; idleStart.Before(start) {
+		idleStart = start
+	}
+	m.idleItem = item
+	m.idleStart = idleStart
+	return true
+}
+
+// startClockIdle shows the idle dialog over the current mode, unless the clock
+// of the idle item was stopped in the meantime
+func (m *uiModel) startClockIdle() {
+	if m.idleItem == nil || !m.idleItem.IsClockedIn() {
+		m.idleItem = nil
+		return
+	}
+	m.clockReturnMode = m.mode
+	m.mode = modeClockIdle
+}
+
+func (m uiModel) updateClockIdle(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		item := m.idleItem
+		switch msg.String() {
+		case "k", "K", "esc":
+			m.setStatus("Idle time kept")
+		case "s":
+			// Stop the clock when the idle time began and start it again now
+			item.ClockOutAt(m.idleStart)
+			item.ClockIn()
+			m.setStatus(fmt.Sprintf("%s of idle time subtracted", formatDuration(time.Since(m.idleStart))))
+		case "S":
+			item.ClockOutAt(m.idleStart)
+			m.setStatus(fmt.Sprintf("Clocked out of %s at %s", item.Title, m.idleStart.Format("15:04")))
+		default:
+			return m, nil
+		}
+		m.idleItem = nil
+		m.mode = m.clockReturnMode
+	}
+	return m, nil
+}
+
+func (m uiModel) viewClockIdle() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Idle Time"))
+	content.WriteString("\n\n")
+	content.WriteString(fmt.Sprintf("You have been idle for %s, since %s, while clocked in to:\n",
+		formatDuration(time.Since(m.idleStart)), m.idleStart.Format("15:04")))
+	content.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).Render(m.idleItem.Title))
+	content.WriteString("\n\n")
+	content.WriteString("K keep the idle time • s subtract it and keep the clock running\n")
+	content.WriteString("S subtract it and clock out")
+
+	dialog := dialogStyle.Render(content.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// clockTickMsg is sent every minute to update the running clock's elapsed time
+type clockTickMsg time.Time
+
+// clockTick waits for the next minute on the system clock
+func clockTick() tea.Cmd {
+	return tea.Every(time.Minute, func(t time.Time) tea.Msg {
+		return clockTickMsg(t)
+	})
+}
+
+// renderClockLine renders the status line with the running clock and pomodoro,
+// or "" if neither is running
+func (m uiModel) renderClockLine() string {
+	if m.mode == modeResolveClock {
+		return ""
+	}
+	clockStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true) // Bright green
+	pomodoro := m.renderPomodoro()
+	item := m.runningClock()
+	if item == nil {
+		if pomodoro == "" {
+			return ""
+		}
+		return clockStyle.Render(pomodoro)
+	}
+
+	elapsed := fmt.Sprintf(" %s", formatDuration(item.GetCurrentClockDuration()))
+	if pomodoro != "" {
+		pomodoro = " " + pomodoro
+	}
+	title := item.Title
+	if m.width > 0 {
+		// Leave room for the brackets, label, elapsed time and pomodoro
+		maxTitle := m.width - len("[CLOCKED IN: ]") - len(elapsed) - len(pomodoro)
+		if maxTitle < 1 {
+			maxTitle = 1
+		}
+		if runes := []rune(title); len(runes) > maxTitle {
+			title = string(runes[:maxTitle-1]) + "…"
+		}
+	}
+	return clockStyle.Render(fmt.Sprintf("[CLOCKED IN: %s%s]%s", title, elapsed, pomodoro))
+}
+
+// jumpToClock moves the cursor to the item the clock is running on
+func (m *uiModel) jumpToClock() {
+	item := m.runningClock()
+	if item == nil {
+		m.setStatus("Not clocked in")
+		return
+	}
+	// Stay in the agenda if it shows the item
+	if m.mode == modeAgenda {
+		if index := m.visibleIndex(item); index >= 0 {
+			m.cursor = index
+			m.setStatus(fmt.Sprintf("Jumped to \"%s\"", item.Title))
+			return
+		}
+	}
+	if !containsItem(m.orgFile.Items, item) {
+		m.setStatus(fmt.Sprintf("\"%s\" is only in the agenda files", item.Title))
+		return
+	}
+	m.jumpToItem(item)
+}
+
+// maxClockHistory is the number of items the clock history offers
+const maxClockHistory = 9
+
+// recentlyClocked returns the items clocked most recently, newest first
+func (m uiModel) recentlyClocked() []*model.Item {
+	all := model.FlattenAllItems(m.orgFile.Items)
+	if m.agendaFiles != nil {
+		all = append(all, model.FlattenAllItems(m.agendaFiles.Items)...)
+	}
+
+	lastClocked := make(map[*model.Item]time.Time)
+	var clocked []*model.Item
+	for _, item := range all {
+		for _, entry := range item.ClockEntries {
+			if entry.Start.After(lastClocked[item]) {
+				lastClocked[item] = entry.Start
+			}
+		}
+		if _, ok := lastClocked[item]; ok {
+			clocked = append(clocked, item)
+		}
+	}
+
+	sort.SliceStable(clocked, func(i, j int) bool {
+		return lastClocked[clocked[i]].After(lastClocked[clocked[j]])
+	})
+	if len(clocked) > maxClockHistory {
+		clocked = clocked[:maxClockHistory]
+	}
+	return clocked
+}
+
+// startClockHistory opens the list of recently clocked items
+func (m *uiModel) startClockHistory() {
+	m.clockHistory = m.recentlyClocked()
+	if len(m.clockHistory) == 0 {
+		m.setStatus("No clocked items")
+		return
+	}
+	m.historyCursor = 0
+	m.clockReturnMode = m.mode
+	m.mode = modeClockHistory
+}
+
+// clockInFromHistory clocks in to an item picked from the clock history
+func (m *uiModel) clockInFromHistory(item *model.Item) {
+	m.mode = m.clockReturnMode
+	m.clockHistory = nil
+	if item.IsClockedIn() {
+		m.setStatus("Already clocked in")
+		return
+	}
+	if previous := m.clockIn(item); previous != nil {
+		m.setStatus(fmt.Sprintf("Clocked out of %s, clocked in to %s!", previous.Title, item.Title))
+	} else {
+		m.setStatus(fmt.Sprintf("Clocked in to %s!", item.Title))
+	}
+}
+
+func (m uiModel) updateClockHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.mode = m.clockReturnMode
+			m.clockHistory = nil
+		case "up", "k":
+			if m.historyCursor > 0 {
+				m.historyCursor--
+			}
+		case "down", "j":
+			if m.historyCursor < len(m.clockHistory)-1 {
+				m.historyCursor++
+			}
+		case "enter":
+			m.clockInFromHistory(m.clockHistory[m.historyCursor])
+		default:
+			// Number keys pick an item directly
+			if s := msg.String(); len(s) == 1 && s[0] >= '1' && s[0] <= '9' {
+				if i := int(s[0] - '1'); i < len(m.clockHistory) {
+					m.clockInFromHistory(m.clockHistory[i])
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m uiModel) viewClockHistory() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("46")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Clock History"))
+	content.WriteString("\n\n")
+
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+	for i, item := range m.clockHistory {
+		line := fmt.Sprintf("%s %s", keyStyle.Render(fmt.Sprintf("[%d]", i+1)), item.Title)
+		if item.IsClockedIn() {
+			line += " " + m.styles.statusStyle.Render("(running)")
+		} else {
+			line += " " + m.styles.statusStyle.Render(formatDuration(item.GetTotalClockDuration()))
+		}
+		if i == m.historyCursor {
+			line = m.styles.cursorStyle.Render(line)
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("↑/↓ or 1-9 to choose • Enter to clock in • ESC to cancel"))
+
+	dialog := dialogStyle.Render(content.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
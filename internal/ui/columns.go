@@ -0,0 +1,574 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// columnDef is a single column of the column view, as in org's #+COLUMNS: syntax
+type columnDef struct {
+	property string // Upper-cased property name (ITEM, TODO, EFFORT, ...)
+	title    string // Header title
+	width    int    // Fixed width, or 0 to fit the content
+	summary  string // Summary type: "+", ":", "X/" or "X%"
+}
+
+var columnSpecPattern = regexp.MustCompile(`%(\d+)?([\w-]+)(?:\(([^)]*)\))?(?:\{([^}]*)\})?`)
+
+// columnTitles are the default header titles of the special properties
+var columnTitles = map[string]string{
+	"ITEM":      "Item",
+	"TODO":      "State",
+	"PRIORITY":  "Pri",
+	"TAGS":      "Tags",
+	"EFFORT":    "Effort",
+	"CLOCKSUM":  "Clocked",
+	"DEADLINE":  "Deadline",
+	"SCHEDULED": "Scheduled",
+	"CLOSED":    "Closed",
+}
+
+// parseColumnSpec parses a column spec such as "%25ITEM %TODO %EFFORT(Estimate){:} %OWNER".
+// Each column is %[width]PROPERTY[(title)][{summary}].
+func parseColumnSpec(spec string) ([]columnDef, error) {
+	var columns []columnDef
+	for _, matches := range columnSpecPattern.FindAllStringSubmatch(spec, -1) {
+		col := columnDef{
+			property: strings.ToUpper(matches[2]),
+			title:    matches[3],
+			summary:  matches[4],
+		}
+		if matches[1] != "" {
+			col.width, _ = strconv.Atoi(matches[1])
+		}
+		if col.title == "" {
+			col.title = columnTitles[col.property]
+		}
+		if col.title == "" {
+			col.title = matches[2]
+		}
+		switch col.summary {
+		case "", "+", ":", "X/", "X%":
+		default:
+			return nil, fmt.Errorf("unsupported summary type {%s} for %s", col.summary, col.property)
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns in spec: %s", spec)
+	}
+	return columns, nil
+}
+
+// columnSpec returns the column spec for the current file: its #+COLUMNS: line, or the configured default
+func (m uiModel) columnSpec() string {
+	preamble := m.orgFile.Preamble
+
+	// In multi-file mode each file's preamble is kept as the notes of its wrapper item
+	items := m.getVisibleItems()
+	if m.cursor < len(items) && items[m.cursor].SourceFile != "" {
+		for _, fileItem := range model.FileItems(m.orgFile.Items) {
+			if fileItem.SourceFile == items[m.cursor].SourceFile {
+				preamble = fileItem.Notes
+				break
+			}
+		}
+	}
+
+	for _, line := range preamble {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) > 10 && strings.EqualFold(trimmed[:10], "#+COLUMNS:") {
+			return strings.TrimSpace(trimmed[10:])
+		}
+	}
+	return m.config.UI.ColumnFormat
+}
+
+// startColumnView opens the column view, reporting spec errors in the status bar
+func (m *uiModel) startColumnView() {
+	columns, err := parseColumnSpec(m.columnSpec())
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Invalid column spec: %v", err))
+		return
+	}
+	m.columns = columns
+	m.columnCursor = 0
+	m.mode = modeColumns
+}
+
+// columnValue returns the raw value of a column for an item
+func (m uiModel) columnValue(item *model.Item, col columnDef) string {
+	switch col.property {
+	case "ITEM":
+		return item.Title
+	case "TODO":
+		return string(item.State)
+	case "PRIORITY":
+		return string(item.Priority)
+	case "TAGS":
+		if len(item.Tags) == 0 {
+			return ""
+		}
+		return ":" + strings.Join(item.Tags, ":") + ":"
+	case "EFFORT":
+		return item.GetEffort()
+	case "CLOCKSUM":
+		if clocked := item.GetSubtreeClockDuration(); clocked > 0 {
+			return formatDuration(clocked)
+		}
+		return ""
+	case "DEADLINE":
+		return formatOptionalDate(item.Deadline)
+	case "SCHEDULED":
+		return formatOptionalDate(item.Scheduled)
+	case "CLOSED":
+		return formatOptionalDate(item.Closed)
+	}
+	return item.GetProperty(col.property)
+}
+
+// formatOptionalDate formats a date for display, or "" if unset
+func formatOptionalDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return parser.FormatOrgDate(*t)
+}
+
+// columnNumber returns the numeric value of a column for summing; durations are in minutes
+func (m uiModel) columnNumber(item *model.Item, col columnDef) (float64, bool) {
+	if col.property == "CLOCKSUM" {
+		clocked := item.GetSubtreeClockDuration()
+		return clocked.Minutes(), clocked > 0
+	}
+	value := m.columnValue(item, col)
+	if col.summary == ":" {
+		d, ok := model.ParseEffort(value, m.effortUnits())
+		return d.Minutes(), ok
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	return n, err == nil
+}
+
+// columnRollup sums a column over a subtree. As in org, children's values
+// replace the item's own value when any of them have one.
+func (m uiModel) columnRollup(item *model.Item, col columnDef) (float64, bool) {
+	// CLOCKSUM already covers the whole subtree
+	if col.property == "CLOCKSUM" {
+		return m.columnNumber(item, col)
+	}
+	var total float64
+	found := false
+	for _, child := range item.Children {
+		if n, ok := m.columnRollup(child, col); ok {
+			total += n
+			found = true
+		}
+	}
+	if found {
+		return total, true
+	}
+	return m.columnNumber(item, col)
+}
+
+// formatColumnNumber formats a summed value for display
+func formatColumnNumber(n float64, col columnDef) string {
+	if col.summary == ":" {
+		return formatDuration(time.Duration(n * float64(time.Minute)))
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// columnCell returns the display text of a cell and whether it shows a computed summary
+func (m uiModel) columnCell(item *model.Item, col columnDef) (string, bool) {
+	if (col.summary == ":" || col.summary == "+") && col.property != "CLOCKSUM" && len(item.Children) > 0 {
+		hasChildValue := false
+		for _, child := range item.Children {
+			if _, ok := m.columnRollup(child, col); ok {
+				hasChildValue = true
+				break
+			}
+		}
+		if hasChildValue {
+			n, _ := m.columnRollup(item, col)
+			return formatColumnNumber(n, col), true
+		}
+	}
+	value := m.columnValue(item, col)
+	if col.property == "ITEM" {
+		value = strings.Repeat("  ", item.Level-1) + value
+	}
+	return value, false
+}
+
+// isDoneValue returns true if a value counts as done for {X/} and {X%} summaries
+func isDoneValue(value string) bool {
+	switch strings.ToUpper(value) {
+	case string(model.StateDONE), "[X]", "X":
+		return true
+	}
+	return false
+}
+
+// columnSummary computes the summary row cell for a column over the given rows
+func (m uiModel) columnSummary(rows []*model.Item, col columnDef) string {
+	switch col.summary {
+	case "+", ":":
+		// Sum only the outermost rows, as their values already include their descendants
+		inRows := make(map[*model.Item]bool)
+		for _, item := range rows {
+			inRows[item] = true
+		}
+		var total float64
+		found := false
+		for _, item := range rows {
+			if parent := m.findParent(item); parent != nil && inRows[parent] {
+				continue
+			}
+			if n, ok := m.columnRollup(item, col); ok {
+				total += n
+				found = true
+			}
+		}
+		if !found {
+			return ""
+		}
+		return formatColumnNumber(total, col)
+	case "X/", "X%":
+		done, total := 0, 0
+		for _, item := range rows {
+			value := m.columnValue(item, col)
+			if value == "" {
+				continue
+			}
+			total++
+			if isDoneValue(value) {
+				done++
+			}
+		}
+		if total == 0 {
+			return ""
+		}
+		if col.summary == "X%" {
+			return fmt.Sprintf("%d%%", 100*done/total)
+		}
+		return fmt.Sprintf("%d/%d", done, total)
+	}
+	return ""
+}
+
+// columnEditable returns true if a column's cells can be edited inline
+func columnEditable(col columnDef) bool {
+	return col.property != "CLOCKSUM" && col.property != "CLOSED"
+}
+
+// columnEditValue returns the initial text of the cell editor
+func (m uiModel) columnEditValue(item *model.Item, col columnDef) string {
+	switch col.property {
+	case "TAGS":
+		return strings.Join(item.Tags, ":")
+	case "DEADLINE":
+		if item.Deadline != nil {
+			return item.Deadline.Format("2006-01-02")
+		}
+		return ""
+	case "SCHEDULED":
+		if item.Scheduled != nil {
+			return item.Scheduled.Format("2006-01-02")
+		}
+		return ""
+	}
+	return m.columnValue(item, col)
+}
+
+// applyColumnEdit validates and stores an edited cell value
+func (m *uiModel) applyColumnEdit(item *model.Item, col columnDef, input string) error {
+	switch col.property {
+	case "ITEM":
+		if input == "" {
+			return fmt.Errorf("title cannot be empty")
+		}
+		item.Title = input
+	case "TODO":
+		state := strings.ToUpper(input)
+		if state != "" && !containsString(m.config.GetStateNames(), state) {
+			return fmt.Errorf("unknown state: %s", input)
+		}
+		if msg := m.blockedMessage(item, model.TodoState(state)); msg != "" {
+			return errors.New(msg)
+		}
+		wasDone := m.isDoneState(item.State)
+		item.State = model.TodoState(state)
+		if m.isDoneState(item.State) && !wasDone {
+			m.unblockDependents(item)
+		}
+	case "PRIORITY":
+		p := model.Priority(strings.ToUpper(input))
+		if p != model.PriorityNone && p != model.PriorityA && p != model.PriorityB && p != model.PriorityC {
+			return fmt.Errorf("invalid priority: %s", input)
+		}
+		item.Priority = p
+	case "TAGS":
+		applyTagInput(item, input)
+	case "EFFORT":
+		if input != "" {
+			if _, ok := model.ParseEffort(input, m.effortUnits()); !ok {
+				return fmt.Errorf("invalid effort: %s", input)
+			}
+		}
+		item.SetEffort(input)
+	case "DEADLINE", "SCHEDULED":
+		if input == "" {
+			setItemDate(item, col.property, nil)
+			break
+		}
+		date, err := parseDateInput(input)
+		if err != nil {
+			return err
+		}
+		setItemDate(item, col.property, &date)
+	default:
+		item.SetProperty(col.property, input)
+	}
+	return nil
+}
+
+// updateColumns handles key presses in the column view
+func (m uiModel) updateColumns(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.columnEditing {
+		return m.updateColumnEdit(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		items := m.getVisibleItems()
+		switch msg.String() {
+		case "esc", "q":
+			m.mode = modeList
+			return m, nil
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(items)-1 {
+				m.cursor++
+			}
+		case "left", "h":
+			if m.columnCursor > 0 {
+				m.columnCursor--
+			}
+		case "right", "l":
+			if m.columnCursor < len(m.columns)-1 {
+				m.columnCursor++
+			}
+		case "tab":
+			if m.cursor < len(items) && len(items[m.cursor].Children) > 0 {
+				items[m.cursor].ToggleFold()
+			}
+		case "enter":
+			if m.cursor >= len(items) {
+				return m, nil
+			}
+			col := m.columns[m.columnCursor]
+			if !columnEditable(col) {
+				m.setStatus(fmt.Sprintf("%s is computed and cannot be edited", col.title))
+				return m, nil
+			}
+			m.editingItem = items[m.cursor]
+			m.columnEditing = true
+			m.textinput.SetValue(m.columnEditValue(m.editingItem, col))
+			m.textinput.Placeholder = col.title
+			m.textinput.CursorEnd()
+			m.textinput.Focus()
+			return m, textinput.Blink
+		default:
+			if key.Matches(msg, m.keys.ColumnView) {
+				m.mode = modeList
+			}
+		}
+	}
+	return m, nil
+}
+
+// updateColumnEdit handles the inline cell editor of the column view
+func (m uiModel) updateColumnEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			col := m.columns[m.columnCursor]
+			if m.editingItem != nil {
+				if err := m.applyColumnEdit(m.editingItem, col, strings.TrimSpace(m.textinput.Value())); err != nil {
+					m.setStatus(err.Error())
+					return m, nil
+				}
+				m.setStatus(fmt.Sprintf("%s updated", col.title))
+			}
+			m.columnEditing = false
+			m.editingItem = nil
+			m.textinput.Blur()
+			return m, nil
+		case tea.KeyEsc:
+			m.columnEditing = false
+			m.editingItem = nil
+			m.textinput.Blur()
+			m.setStatus("Cancelled")
+			return m, nil
+		}
+	}
+
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
+
+// viewColumns renders the visible items as a table of the configured columns
+func (m uiModel) viewColumns() string {
+	rows := m.getVisibleItems()
+	separator := m.styles.foldedStyle.Render(" │ ")
+
+	// Compute each column's width from its header, cells and summary
+	summaries := make([]string, len(m.columns))
+	widths := make([]int, len(m.columns))
+	for i, col := range m.columns {
+		summaries[i] = m.columnSummary(rows, col)
+		if col.width > 0 {
+			widths[i] = col.width
+			continue
+		}
+		widths[i] = lipgloss.Width(col.title)
+		if w := lipgloss.Width(summaries[i]); w > widths[i] {
+			widths[i] = w
+		}
+		for _, item := range rows {
+			text, _ := m.columnCell(item, col)
+			if w := lipgloss.Width(text); w > widths[i] {
+				widths[i] = w
+			}
+		}
+		if widths[i] > 40 {
+			widths[i] = 40
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(m.styles.titleStyle.Render("Column View"))
+	if status := m.filterStatus(); status != "" {
+		b.WriteString("  ")
+		b.WriteString(m.styles.scheduledStyle.Render(status))
+	}
+	b.WriteString("\n\n")
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	var header []string
+	for i, col := range m.columns {
+		header = append(header, headerStyle.Render(padCell(col.title, widths[i])))
+	}
+	b.WriteString(strings.Join(header, separator))
+	b.WriteString("\n")
+
+	// Keep the cursor row in view
+	availableHeight := m.height - 8
+	if availableHeight < 3 {
+		availableHeight = 3
+	}
+	start := 0
+	if m.cursor >= availableHeight {
+		start = m.cursor - availableHeight + 1
+	}
+	end := start + availableHeight
+	if end > len(rows) {
+		end = len(rows)
+	}
+
+	summaryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141"))
+	for r := start; r < end; r++ {
+		item := rows[r]
+		var cells []string
+		for i, col := range m.columns {
+			text, computed := m.columnCell(item, col)
+			cell := padCell(text, widths[i])
+			switch {
+			case r == m.cursor && i == m.columnCursor:
+				cell = lipgloss.NewStyle().Reverse(true).Render(cell)
+			case col.property == "TODO" && text != "":
+				cell = lipgloss.NewStyle().Foreground(lipgloss.Color(m.config.GetStateColor(text))).Render(cell)
+			case computed:
+				cell = summaryStyle.Render(cell)
+			case r == m.cursor:
+				cell = m.styles.cursorStyle.Render(cell)
+			}
+			cells = append(cells, cell)
+		}
+		b.WriteString(strings.Join(cells, separator))
+		b.WriteString("\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString(m.styles.statusStyle.Render("No items"))
+		b.WriteString("\n")
+	}
+
+	// Summary row
+	var summaryCells []string
+	hasSummary := false
+	for i := range m.columns {
+		text := summaries[i]
+		if text != "" {
+			hasSummary = true
+		}
+		if i == 0 && text == "" {
+			text = "Summary"
+		}
+		summaryCells = append(summaryCells, headerStyle.Render(padCell(text, widths[i])))
+	}
+	if hasSummary {
+		b.WriteString(strings.Join(summaryCells, separator))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.columnEditing {
+		b.WriteString(fmt.Sprintf("%s: %s\n", m.columns[m.columnCursor].title, m.textinput.View()))
+		b.WriteString(m.styles.statusStyle.Render("Press Enter to save • ESC to cancel"))
+	} else {
+		if m.statusMsg != "" && time.Now().Before(m.statusExpiry) {
+			b.WriteString(m.styles.statusStyle.Render(m.statusMsg))
+			b.WriteString("\n")
+		}
+		b.WriteString(m.styles.statusStyle.Render("←/→/↑/↓ move • Enter edit cell • Tab fold • ESC to close"))
+	}
+	return b.String()
+}
+
+// padCell truncates or pads text to exactly the given display width
+func padCell(text string, width int) string {
+	if lipgloss.Width(text) > width {
+		runes := []rune(text)
+		for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+			runes = runes[:len(runes)-1]
+		}
+		text = string(runes) + "…"
+	}
+	return text + strings.Repeat(" ", width-lipgloss.Width(text))
+}
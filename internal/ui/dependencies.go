@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// isDoneState returns true if the state is a final configured state (typically DONE)
+func (m uiModel) isDoneState(state model.TodoState) bool {
+	return m.config.IsDoneState(string(state))
+}
+
+// isOpenTask returns true if the item is a task that has not been completed
+func (m uiModel) isOpenTask(item *model.Item) bool {
+	return item.State != model.StateNone && !m.isDoneState(item.State)
+}
+
+// isOrdered returns true if the item's children must be completed in order (:ORDERED: t)
+func isOrdered(item *model.Item) bool {
+	value := strings.ToLower(item.GetProperty("ORDERED"))
+	return value != "" && value != "nil"
+}
+
+// openBlockers returns the unfinished items that block the given item: items listed
+// in its :BLOCKER: property, and its previous siblings if its parent is :ORDERED:
+func (m uiModel) openBlockers(item *model.Item) []*model.Item {
+	var blockers []*model.Item
+	for _, id := range item.GetBlockerIDs() {
+		if blocker := m.orgFile.FindByID(id); blocker != nil && blocker != item && m.isOpenTask(blocker) {
+			blockers = append(blockers, blocker)
+		}
+	}
+
+	if parent := m.findParent(item); parent != nil && isOrdered(parent) {
+		for _, sibling := range parent.Children {
+			if sibling == item {
+				break
+			}
+			if m.isOpenTask(sibling) && !containsBlocker(blockers, sibling) {
+				blockers = append(blockers, sibling)
+			}
+		}
+	}
+	return blockers
+}
+
+// containsBlocker returns true if the item is already in the list of blockers
+func containsBlocker(blockers []*model.Item, item *model.Item) bool {
+	for _, blocker := range blockers {
+		if blocker == item {
+			return true
+		}
+	}
+	return false
+}
+
+// blockerTitles formats the titles of blockers for display
+func blockerTitles(blockers []*model.Item) string {
+	var titles []string
+	for _, blocker := range blockers {
+		titles = append(titles, fmt.Sprintf("\"%s\"", blocker.Title))
+	}
+	return strings.Join(titles, ", ")
+}
+
+// blockedMessage returns a message explaining why the item cannot move to the new
+// state, or "" if the change would not complete a blocked item
+func (m uiModel) blockedMessage(item *model.Item, newState model.TodoState) string {
+	if !m.isDoneState(newState) || m.isDoneState(item.State) {
+		return ""
+	}
+	if blockers := m.openBlockers(item); len(blockers) > 0 {
+		return fmt.Sprintf("Cannot complete \"%s\": blocked by %s", item.Title, blockerTitles(blockers))
+	}
+	return ""
+}
+
+// isDependentOf returns true if the blocker is one of the item's dependencies
+func (m uiModel) isDependentOf(item, blocker *model.Item) bool {
+	if id := blocker.GetProperty("ID"); id != "" && containsString(item.GetBlockerIDs(), id) {
+		return true
+	}
+	if parent := m.findParent(item); parent != nil && isOrdered(parent) {
+		for _, sibling := range parent.Children {
+			if sibling == item {
+				return false
+			}
+			if sibling == blocker {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unblockDependents moves items in the BLOCK state back to the first state once
+// the completed item was their last open blocker. Returns the number of items unblocked.
+func (m *uiModel) unblockDependents(completed *model.Item) int {
+	stateNames := m.config.GetStateNames()
+	if !m.config.Dependencies.UnblockDependents || len(stateNames) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, item := range model.FlattenAllItems(m.orgFile.Items) {
+		if item.State != model.StateBLOCK || !m.isDependentOf(item, completed) {
+			continue
+		}
+		if len(m.openBlockers(item)) == 0 {
+			item.State = model.TodoState(stateNames[0])
+			count++
+		}
+	}
+	return count
+}
+
+// changeStates cycles the state of each target, refusing to complete blocked items.
+// Items reaching the done state are clocked out and unblock their dependents.
+func (m *uiModel) changeStates(targets []*model.Item, forward bool) {
+	changed, unblocked := 0, 0
+	for _, item := range targets {
+		var ok bool
+		if forward {
+			ok = m.cycleStateForward(item)
+		} else {
+			ok = m.cycleStateBackward(item)
+		}
+		if !ok {
+			continue
+		}
+		changed++
+
+		if m.isDoneState(item.State) {
+			// Auto clock out when changing to the done state
+			if item.IsClockedIn() {
+				item.ClockOut()
+			}
+			unblocked += m.unblockDependents(item)
+		}
+	}
+
+	// A single blocked item keeps the status set by the refused state change
+	blocked := len(targets) - changed
+	if changed == 0 {
+		if blocked > 1 {
+			m.setStatus(fmt.Sprintf("All %d items are blocked", blocked))
+		}
+		return
+	}
+
+	msg := "State changed"
+	if blocked > 0 {
+		msg = fmt.Sprintf("State changed, %d blocked", blocked)
+	}
+	if unblocked > 0 {
+		msg = fmt.Sprintf("%s, %d unblocked", msg, unblocked)
+	}
+	m.reportBulkAction(changed, msg)
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// editorFinishedMsg reports that the external editor has exited
+type editorFinishedMsg struct {
+	item    *model.Item
+	path    string // Temporary file holding the edited text
+	subtree bool   // Whether the file holds the whole subtree or only the notes
+	err     error
+}
+
+// editorCommand returns the external editor command from the config, $VISUAL or
+// $EDITOR, or nil if none is set
+func (m uiModel) editorCommand() []string {
+	editor := m.config.UI.Editor
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	return strings.Fields(editor)
+}
+
+// startEditNotes opens the built-in editor for the notes of the item under the cursor
+func (m *uiModel) startEditNotes() tea.Cmd {
+	item := m.editableItem()
+	if item == nil {
+		return nil
+	}
+
+	m.editingItem = item
+	m.mode = modeEdit
+	m.textarea.SetValue(strings.Join(item.Notes, "\n"))
+	m.textarea.Focus()
+	return textarea.Blink
+}
+
+// editableItem returns the item under the cursor if its notes can be edited
+func (m *uiModel) editableItem() *model.Item {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return nil
+	}
+	item := items[m.cursor]
+
+	// Prevent editing notes for file and directory items in multi-file mode
+	if item.IsWrapper() {
+		m.setStatus("Cannot add notes to file or directory items")
+		return nil
+	}
+	return item
+}
+
+// startExternalEdit opens the notes of the item under the cursor, or its whole
+// subtree as org text, in the external editor. Without an editor, notes are
+// edited in the built-in editor instead.
+func (m *uiModel) startExternalEdit(subtree bool) tea.Cmd {
+	command := m.editorCommand()
+	if len(command) == 0 {
+		if subtree {
+			m.setStatus("Set $EDITOR or editor in the [ui] config to edit subtrees")
+			return nil
+		}
+		cmd := m.startEditNotes()
+		if cmd != nil {
+			m.setStatus("No $EDITOR set, using the built-in editor")
+		}
+		return cmd
+	}
+
+	item := m.editableItem()
+	if item == nil {
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "org-edit-*.org")
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Failed to create temporary file: %v", err))
+		return nil
+	}
+	path := file.Name()
+	file.Close()
+
+	if subtree {
+		err = parser.SaveSubtree(path, item)
+	} else {
+		var text string
+		if len(item.Notes) > 0 {
+			text = strings.Join(item.Notes, "\n") + "\n"
+		}
+		err = os.WriteFile(path, []byte(text), 0600)
+	}
+	if err != nil {
+		os.Remove(path)
+		m.setStatus(fmt.Sprintf("Failed to write temporary file: %v", err))
+		return nil
+	}
+
+	cmd := exec.Command(command[0], append(command[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{item: item, path: path, subtree: subtree, err: err}
+	})
+}
+
+// applyExternalEdit parses the file written by the external editor back into the item
+func (m *uiModel) applyExternalEdit(msg editorFinishedMsg) {
+	if msg.err != nil {
+		os.Remove(msg.path)
+		m.setStatus(fmt.Sprintf("Editor failed: %v", msg.err))
+		return
+	}
+
+	parsed, err := parser.ParseOrgFile(msg.path, m.config)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Failed to read edited file %s: %v", msg.path, err))
+		return
+	}
+
+	if msg.subtree {
+		if !m.replaceSubtree(msg.item, parsed, msg.path) {
+			return
+		}
+	} else {
+		msg.item.Notes = model.AlignTables(parsed.Preamble)
+
+		// Headings written below the notes become children of the item
+		for _, child := range parsed.Items {
+			m.adjustItemLevels(child, msg.item.Level+1-child.Level)
+			setSourceFile(child, msg.item.SourceFile)
+		}
+		msg.item.Children = append(parsed.Items, msg.item.Children...)
+		if len(parsed.Items) > 0 {
+			msg.item.Folded = false
+			m.setStatus(fmt.Sprintf("Notes updated, %d items added", len(parsed.Items)))
+		} else {
+			m.setStatus("Notes updated")
+		}
+	}
+
+	os.Remove(msg.path)
+	m.orgFile.BuildIDIndex()
+}
+
+// replaceSubtree replaces an item and its children with the items parsed from the
+// edited file. Text before the first heading has no place in the tree, so the
+// subtree is left unchanged and the file kept if there is any.
+func (m *uiModel) replaceSubtree(item *model.Item, parsed *model.OrgFile, path string) bool {
+	hasPreamble := false
+	for _, line := range parsed.Preamble {
+		if strings.TrimSpace(line) != "" {
+			hasPreamble = true
+			break
+		}
+	}
+	if len(parsed.Items) == 0 || hasPreamble {
+		m.setStatus(fmt.Sprintf("Edited subtree must start with a heading, changes kept in %s", path))
+		return false
+	}
+
+	delta := item.Level - parsed.Items[0].Level
+	for _, replacement := range parsed.Items {
+		m.adjustItemLevels(replacement, delta)
+		setSourceFile(replacement, item.SourceFile)
+	}
+
+	siblings := &m.orgFile.Items
+	if parent := m.findParent(item); parent != nil {
+		siblings = &parent.Children
+	}
+	for i, sibling := range *siblings {
+		if sibling == item {
+			updated := append([]*model.Item{}, (*siblings)[:i]...)
+			updated = append(updated, parsed.Items...)
+			*siblings = append(updated, (*siblings)[i+1:]...)
+			break
+		}
+	}
+
+	if m.narrowRoot == item {
+		m.narrowRoot = parsed.Items[0]
+	}
+	// Marks may refer to items that no longer exist
+	m.clearSelection()
+	if index := m.visibleIndex(parsed.Items[0]); index >= 0 {
+		m.cursor = index
+	}
+
+	if len(parsed.Items) > 1 {
+		m.setStatus(fmt.Sprintf("Subtree updated, %d items added", len(parsed.Items)-1))
+	} else {
+		m.setStatus("Subtree updated")
+	}
+	return true
+}
+
+// setSourceFile sets the file an item and its children belong to
+func setSourceFile(item *model.Item, path string) {
+	item.SourceFile = path
+	for _, child := range item.Children {
+		setSourceFile(child, path)
+	}
+}
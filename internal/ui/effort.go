@@ -0,0 +1,238 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// effortUnits returns the configured working-time length of effort units
+func (m uiModel) effortUnits() model.EffortUnits {
+	return model.EffortUnits{
+		HoursPerDay: m.config.Effort.HoursPerDay,
+		DaysPerWeek: m.config.Effort.DaysPerWeek,
+	}
+}
+
+// formatDuration formats a duration as "Xh Ym", or "Ym" for durations under an hour
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// renderEffort renders an item's effort estimate, subtree rollup and budget warning
+func (m uiModel) renderEffort(item *model.Item) string {
+	effortStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")) // Purple
+	units := m.effortUnits()
+
+	var b strings.Builder
+	if effort := item.GetEffort(); effort != "" {
+		if _, ok := model.ParseEffort(effort, units); ok {
+			b.WriteString(effortStyle.Render(fmt.Sprintf(" (Effort: %s)", effort)))
+		} else {
+			b.WriteString(m.styles.overdueStyle.Render(fmt.Sprintf(" (Effort: %s?)", effort)))
+		}
+	}
+
+	estimate, ok := item.GetEffortRollup(units)
+	if !ok {
+		return b.String()
+	}
+
+	// Show the summed estimate of the subtree when children carry their own estimates
+	own, ownOk := model.ParseEffort(item.GetEffort(), units)
+	if len(item.Children) > 0 && (!ownOk || own != estimate) {
+		b.WriteString(effortStyle.Render(fmt.Sprintf(" (Σ Effort: %s)", formatDuration(estimate))))
+	}
+
+	clocked := item.GetSubtreeClockDuration()
+	if estimate > 0 && clocked > estimate {
+		warning := fmt.Sprintf(" [OVER BUDGET: %s / %s]", formatDuration(clocked), formatDuration(estimate))
+		b.WriteString(m.styles.overdueStyle.Bold(true).Render(warning))
+	}
+	return b.String()
+}
+
+// effortReportRow is a single line of the effort report
+type effortReportRow struct {
+	title    string
+	level    int
+	estimate time.Duration
+	clocked  time.Duration
+	hasEst   bool
+}
+
+// effortReportRows collects the projects shown in the effort report: each top-level
+// item and its direct children, skipping items with neither an estimate nor clocked time
+func (m uiModel) effortReportRows() []effortReportRow {
+	roots := m.orgFile.Items
+	if m.narrowRoot != nil {
+		roots = []*model.Item{m.narrowRoot}
+	}
+
+	units := m.effortUnits()
+	var rows []effortReportRow
+	for _, root := range roots {
+		for _, item := range append([]*model.Item{root}, root.Children...) {
+			estimate, hasEst := item.GetEffortRollup(units)
+			clocked := item.GetSubtreeClockDuration()
+			if !hasEst && clocked == 0 {
+				continue
+			}
+			level := 0
+			if item != root {
+				level = 1
+			}
+			rows = append(rows, effortReportRow{
+				title:    item.Title,
+				level:    level,
+				estimate: estimate,
+				clocked:  clocked,
+				hasEst:   hasEst,
+			})
+		}
+	}
+	return rows
+}
+
+// updateEffortReport handles key presses in the effort report view
+func (m uiModel) updateEffortReport(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch {
+		case msg.String() == "esc" || msg.String() == "q" || key.Matches(msg, m.keys.EffortReport):
+			m.mode = modeList
+			m.reportScroll = 0
+		case msg.String() == "up" || msg.String() == "k":
+			if m.reportScroll > 0 {
+				m.reportScroll--
+			}
+		case msg.String() == "down" || msg.String() == "j":
+			if m.reportScroll < reportMaxScroll(len(m.effortReportLines()), m.viewHeight()) {
+				m.reportScroll++
+			}
+		}
+	}
+	return m, nil
+}
+
+// viewEffortReport renders estimated versus clocked effort per project
+func (m uiModel) viewEffortReport() string {
+	lines := m.effortReportLines()
+
+	// Clamp scrolling to the available height
+	start := m.reportScroll
+	if maxScroll := reportMaxScroll(len(lines), m.height); start > maxScroll {
+		start = maxScroll
+	}
+	end := start + reportHeight(m.height)
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	var content strings.Builder
+	for _, line := range lines[start:end] {
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("↑/↓ scroll • ESC to close"))
+	return content.String()
+}
+
+// reportHeight returns the number of report lines shown in a view of the given height
+func reportHeight(height int) int {
+	if height-2 < 5 {
+		return 5
+	}
+	return height - 2
+}
+
+// reportMaxScroll returns the furthest a report of lineCount lines can scroll
+// in a view of the given height
+func reportMaxScroll(lineCount, height int) int {
+	if maxScroll := lineCount - reportHeight(height); maxScroll > 0 {
+		return maxScroll
+	}
+	return 0
+}
+
+// effortReportLines renders the lines of the effort report
+func (m uiModel) effortReportLines() []string {
+	rows := m.effortReportRows()
+
+	titleWidth := 36
+	if m.width > 0 && m.width-46 < titleWidth {
+		titleWidth = m.width - 46
+	}
+	if titleWidth < 10 {
+		titleWidth = 10
+	}
+
+	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	rowFormat := fmt.Sprintf("%%-%ds %%10s %%10s %%10s %%6s", titleWidth)
+
+	var lines []string
+	lines = append(lines, m.styles.titleStyle.Render("Effort Report"))
+	lines = append(lines, m.styles.statusStyle.Render(fmt.Sprintf("1d = %gh, 1w = %gd", m.config.Effort.HoursPerDay, m.config.Effort.DaysPerWeek)))
+	lines = append(lines, "")
+	lines = append(lines, headerStyle.Render(fmt.Sprintf(rowFormat, "Project", "Estimate", "Clocked", "Left", "Done")))
+
+	var totalEstimate, totalClocked time.Duration
+	for _, row := range rows {
+		title := strings.Repeat("  ", row.level) + row.title
+		if len([]rune(title)) > titleWidth {
+			title = string([]rune(title)[:titleWidth-1]) + "…"
+		}
+
+		estimate, left, progress := "-", "-", "-"
+		if row.hasEst {
+			estimate = formatDuration(row.estimate)
+			left = formatDuration(row.estimate - row.clocked)
+			if row.estimate > 0 {
+				progress = fmt.Sprintf("%d%%", int(100*row.clocked/row.estimate))
+			}
+		}
+		line := fmt.Sprintf(rowFormat, title, estimate, formatDuration(row.clocked), left, progress)
+
+		switch {
+		case row.hasEst && row.clocked > row.estimate:
+			line = m.styles.overdueStyle.Render(line)
+		case row.level == 0:
+			line = lipgloss.NewStyle().Bold(true).Render(line)
+		}
+		lines = append(lines, line)
+
+		// Only top-level rows count towards the totals, as they already include their children
+		if row.level == 0 {
+			totalEstimate += row.estimate
+			totalClocked += row.clocked
+		}
+	}
+
+	if len(rows) == 0 {
+		lines = append(lines, m.styles.statusStyle.Render("No effort estimates or clocked time"))
+	} else {
+		lines = append(lines, "")
+		lines = append(lines, headerStyle.Render(fmt.Sprintf(rowFormat, "Total", formatDuration(totalEstimate),
+			formatDuration(totalClocked), formatDuration(totalEstimate-totalClocked), "")))
+	}
+
+	return lines
+}
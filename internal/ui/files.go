@@ -0,0 +1,451 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// startNewFile opens the prompt for a file to create next to the item under the cursor
+func (m *uiModel) startNewFile() tea.Cmd {
+	if !m.orgFile.IsMultiFile() {
+		m.setStatus("New files can only be created in multi-file mode")
+		return nil
+	}
+	m.mode = modeNewFile
+	m.textinput.SetValue("")
+	m.textinput.Placeholder = "File name, e.g. notes.org"
+	m.textinput.Focus()
+	return textinput.Blink
+}
+
+// updateNewFile handles the new file prompt
+func (m uiModel) updateNewFile(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.createFile(m.textinput.Value())
+			m.mode = modeList
+			m.textinput.Blur()
+			return m, nil
+		case tea.KeyEsc:
+			m.mode = modeList
+			m.textinput.Blur()
+			m.setStatus("Cancelled")
+			return m, nil
+		}
+	}
+
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
+
+// viewNewFile renders the new file prompt
+func (m uiModel) viewNewFile() string {
+	var content strings.Builder
+
+	dir, _ := m.newFileLocation()
+	content.WriteString(m.styles.titleStyle.Render("New File") + "\n\n")
+	content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("In: %s", dir)) + "\n\n")
+	content.WriteString(m.textinput.View() + "\n\n")
+	content.WriteString(m.styles.statusStyle.Render(".org is added if the name has no extension") + "\n\n")
+	content.WriteString(m.styles.statusStyle.Render("Press Enter to create • ESC to cancel") + "\n")
+
+	return content.String()
+}
+
+// newFileLocation returns the directory a new file goes in, and the directory item
+// to add it to (nil for the top level). The file goes in the directory under the
+// cursor, or next to the file the cursor is in.
+func (m uiModel) newFileLocation() (string, *model.Item) {
+	items := m.getVisibleItems()
+	if m.cursor < len(items) {
+		item := items[m.cursor]
+		if item.Wrapper == model.DirWrapper {
+			return item.SourceFile, item
+		}
+		if fileItem := m.findTopLevelFileItem(items, m.cursor); fileItem != nil {
+			return filepath.Dir(fileItem.SourceFile), m.findParent(fileItem)
+		}
+	}
+	return m.orgFile.Path, nil
+}
+
+// createFile creates an empty org file and adds an item for it to the tree
+func (m *uiModel) createFile(input string) {
+	name, err := cleanFileName(input, ".org")
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Cannot create file: %v", err))
+		return
+	}
+	dir, parent := m.newFileLocation()
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		m.setStatus(fmt.Sprintf("Cannot create file: %s already exists", path))
+		return
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		m.setStatus(fmt.Sprintf("Cannot create file: %v", err))
+		return
+	}
+
+	fileItem := &model.Item{
+		Level:      1,
+		State:      model.StateNone,
+		Priority:   model.PriorityNone,
+		Title:      name,
+		Tags:       []string{},
+		Children:   []*model.Item{},
+		SourceFile: path,
+		Wrapper:    model.FileWrapper,
+	}
+	if parent != nil {
+		fileItem.Level = parent.Level + 1
+		parent.Children = insertFileItem(parent.Children, fileItem)
+		parent.Folded = false
+	} else {
+		m.orgFile.Items = insertFileItem(m.orgFile.Items, fileItem)
+	}
+
+	if index := m.visibleIndex(fileItem); index >= 0 {
+		m.cursor = index
+	}
+	m.setStatus(fmt.Sprintf("Created %s", path))
+}
+
+// insertFileItem adds a file item among its siblings in name order, before any
+// directory items
+func insertFileItem(siblings []*model.Item, fileItem *model.Item) []*model.Item {
+	i := 0
+	for i < len(siblings) && siblings[i].Wrapper == model.FileWrapper && siblings[i].Title < fileItem.Title {
+		i++
+	}
+	return append(siblings[:i], append([]*model.Item{fileItem}, siblings[i:]...)...)
+}
+
+// cleanFileName checks a file name typed into a prompt, adding ext if it has no
+// extension
+func cleanFileName(input, ext string) (string, error) {
+	name := strings.TrimSpace(input)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("enter a file name")
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("the name can't contain directories")
+	}
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+	return name, nil
+}
+
+// renameFile renames the file of a file item on disk, keeping its extension if
+// the new name has none
+func (m *uiModel) renameFile(fileItem *model.Item, input string) {
+	name, err := cleanFileName(input, filepath.Ext(fileItem.SourceFile))
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Cannot rename file: %v", err))
+		return
+	}
+	if name == fileItem.Title {
+		return
+	}
+	path := filepath.Join(filepath.Dir(fileItem.SourceFile), name)
+	if _, err := os.Stat(path); err == nil {
+		m.setStatus(fmt.Sprintf("Cannot rename file: %s already exists", path))
+		return
+	}
+	// A file that isn't on disk yet is written under the new name on save
+	if err := os.Rename(fileItem.SourceFile, path); err != nil && !os.IsNotExist(err) {
+		m.setStatus(fmt.Sprintf("Cannot rename file: %v", err))
+		return
+	}
+
+	fileItem.Title = name
+	setSourceFile(fileItem, path)
+	m.setStatus(fmt.Sprintf("File renamed to %s", name))
+}
+
+// canDelete refuses to delete directory items, and file items together with other
+// items, since deleting a file item moves the file to the trash
+func (m *uiModel) canDelete() bool {
+	for _, item := range m.promptTargets() {
+		if item.Wrapper == model.DirWrapper {
+			m.setStatus("Cannot delete directory items")
+			return false
+		}
+		if item.Wrapper == model.FileWrapper && len(m.bulkItems) > 0 {
+			m.setStatus("Files can only be deleted one at a time")
+			return false
+		}
+	}
+	return true
+}
+
+// trashFile moves a file to the trash, so it can be restored: ~/.Trash on macOS,
+// and the freedesktop.org trash of the user elsewhere
+func trashFile(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "darwin" {
+		target, err := createUnique(filepath.Join(home, ".Trash"), filepath.Base(path), "")
+		if err != nil {
+			return err
+		}
+		return moveFile(path, target)
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	trashDir := filepath.Join(dataHome, "Trash")
+	if err := os.MkdirAll(filepath.Join(trashDir, "files"), 0700); err != nil {
+		return err
+	}
+
+	// The info file records where the file came from, and reserves its name in the trash
+	info, err := createUnique(filepath.Join(trashDir, "info"), filepath.Base(path), ".trashinfo")
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	if err := os.WriteFile(info, []byte(text), 0600); err != nil {
+		os.Remove(info)
+		return err
+	}
+
+	target := filepath.Join(trashDir, "files", strings.TrimSuffix(filepath.Base(info), ".trashinfo"))
+	if err := moveFile(path, target); err != nil {
+		os.Remove(info)
+		return err
+	}
+	return nil
+}
+
+// createUnique creates an empty file named name+suffix in dir, adding a number to
+// the name if it is taken, and returns its path
+func createUnique(dir, name, suffix string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s.%d%s", stem, n, ext)
+		}
+		path := filepath.Join(dir, candidate+suffix)
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		file.Close()
+		return path, nil
+	}
+}
+
+// moveFile moves a file, copying it if it is on another file system
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(to, data, 0600); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// startMoveToFile opens the file picker for moving the item under the cursor, or
+// the selection, to another file
+func (m *uiModel) startMoveToFile() {
+	if !m.orgFile.IsMultiFile() {
+		m.setStatus("Items can only be moved between files in multi-file mode")
+		return
+	}
+	if !m.startPrompt() {
+		return
+	}
+	for _, item := range m.promptTargets() {
+		if item.IsWrapper() {
+			m.setStatus("Cannot move file or directory items")
+			m.finishPrompt(false)
+			return
+		}
+		if !containsItem(m.orgFile.Items, item) {
+			m.setStatus("Cannot move items of other agenda files")
+			m.finishPrompt(false)
+			return
+		}
+	}
+
+	m.fileTargets = model.FileItems(m.orgFile.Items)
+	m.fileCursor = 0
+	for i, fileItem := range m.fileTargets {
+		if fileItem.SourceFile == m.editingItem.SourceFile {
+			m.fileCursor = i
+			break
+		}
+	}
+	m.mode = modeMoveToFile
+}
+
+// updateMoveToFile handles the file picker for moving items
+func (m uiModel) updateMoveToFile(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch {
+		case msg.Type == tea.KeyEsc, key.Matches(msg, m.keys.Quit):
+			m.mode = modeList
+			m.fileTargets = nil
+			m.finishPrompt(false)
+			m.setStatus("Cancelled")
+		case key.Matches(msg, m.keys.Up):
+			if m.fileCursor > 0 {
+				m.fileCursor--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if m.fileCursor < len(m.fileTargets)-1 {
+				m.fileCursor++
+			}
+		case msg.Type == tea.KeyEnter:
+			if m.fileCursor < len(m.fileTargets) {
+				m.moveToFile(m.fileTargets[m.fileCursor])
+			}
+			m.mode = modeList
+			m.fileTargets = nil
+		}
+	}
+	return m, nil
+}
+
+// viewMoveToFile renders the file picker for moving items
+func (m uiModel) viewMoveToFile() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("99")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	if len(m.bulkItems) > 0 {
+		content.WriteString(m.styles.titleStyle.Render(fmt.Sprintf("Move %d Items to File", len(m.bulkItems))))
+	} else {
+		content.WriteString(m.styles.titleStyle.Render("Move to File"))
+	}
+	content.WriteString("\n\n")
+
+	// Show a window of files around the cursor
+	start := 0
+	if m.fileCursor >= 10 {
+		start = m.fileCursor - 9
+	}
+	for i := start; i < len(m.fileTargets) && i < start+10; i++ {
+		line := m.fileLabel(m.fileTargets[i])
+		if i == m.fileCursor {
+			line = m.styles.cursorStyle.Render(line)
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("↑/↓ to choose • Enter to move • ESC to cancel"))
+
+	dialog := dialogStyle.Render(content.String())
+
+	// Center the dialog horizontally and vertically
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// fileLabel returns the path of a file item relative to the loaded directory
+func (m uiModel) fileLabel(fileItem *model.Item) string {
+	if m.orgFile.Path != "" {
+		if rel, err := filepath.Rel(m.orgFile.Path, fileItem.SourceFile); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return fileItem.SourceFile
+}
+
+// moveToFile moves the prompt targets, with their children, to the end of a file
+func (m *uiModel) moveToFile(fileItem *model.Item) {
+	targets := m.promptTargets()
+	moved := 0
+	for _, item := range targets {
+		// Children of a moved item move along with it
+		if m.hasAncestorIn(item, targets) {
+			continue
+		}
+
+		siblings := &m.orgFile.Items
+		if parent := m.findParent(item); parent != nil {
+			siblings = &parent.Children
+		}
+		for i, sibling := range *siblings {
+			if sibling == item {
+				*siblings = append((*siblings)[:i:i], (*siblings)[i+1:]...)
+				break
+			}
+		}
+
+		m.adjustItemLevels(item, fileItem.Level+1-item.Level)
+		setSourceFile(item, fileItem.SourceFile)
+		fileItem.Children = append(fileItem.Children, item)
+		moved++
+	}
+	m.finishPrompt(true)
+
+	if items := m.getVisibleItems(); m.cursor >= len(items) && len(items) > 0 {
+		m.cursor = len(items) - 1
+	}
+	if moved > 1 {
+		m.setStatus(fmt.Sprintf("%d items moved to %s", moved, fileItem.Title))
+	} else {
+		m.setStatus(fmt.Sprintf("Moved to %s", fileItem.Title))
+	}
+}
+
+// hasAncestorIn returns true if any parent of the item is in the list
+func (m *uiModel) hasAncestorIn(item *model.Item, list []*model.Item) bool {
+	for parent := m.findParent(item); parent != nil; parent = m.findParent(parent) {
+		for _, other := range list {
+			if other == parent {
+				return true
+			}
+		}
+	}
+	return false
+}
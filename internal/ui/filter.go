@@ -0,0 +1,263 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// itemFilter narrows the list view to items matching a query
+type itemFilter struct {
+	query            string
+	tags             []string
+	excludeTags      []string
+	states           []string
+	excludeStates    []string
+	priorities       []model.Priority
+	files            []string
+	text             []string
+	requireDeadline  bool
+	requireScheduled bool
+}
+
+// parseFilter parses a filter query such as "tag:work -state:DONE prio:A,B has:deadline".
+//
+// Supported terms:
+//
+//	tag:a,b      items with any of the tags
+//	-tag:a       items without the tag
+//	state:A,B    items in any of the states
+//	-state:DONE  items not in the state
+//	prio:A,B     items with any of the priorities
+//	has:deadline items with a deadline (also has:scheduled)
+//	file:name    items from a source file (multi-file mode)
+//	word         items whose title contains the word
+func parseFilter(query string) (*itemFilter, error) {
+	f := &itemFilter{query: strings.TrimSpace(query)}
+
+	for _, term := range strings.Fields(query) {
+		exclude := strings.HasPrefix(term, "-")
+		term = strings.TrimPrefix(term, "-")
+
+		key, value, hasKey := strings.Cut(term, ":")
+		if !hasKey {
+			if exclude {
+				return nil, fmt.Errorf("cannot exclude plain text: -%s", term)
+			}
+			f.text = append(f.text, strings.ToLower(term))
+			continue
+		}
+
+		values := splitFilterValues(value)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("missing value for %s:", key)
+		}
+
+		switch strings.ToLower(key) {
+		case "tag":
+			if exclude {
+				f.excludeTags = append(f.excludeTags, values...)
+			} else {
+				f.tags = append(f.tags, values...)
+			}
+		case "state":
+			for i, v := range values {
+				values[i] = strings.ToUpper(v)
+			}
+			if exclude {
+				f.excludeStates = append(f.excludeStates, values...)
+			} else {
+				f.states = append(f.states, values...)
+			}
+		case "prio", "priority":
+			if exclude {
+				return nil, fmt.Errorf("cannot exclude priorities")
+			}
+			for _, v := range values {
+				p := model.Priority(strings.ToUpper(v))
+				if p != model.PriorityA && p != model.PriorityB && p != model.PriorityC {
+					return nil, fmt.Errorf("invalid priority: %s", v)
+				}
+				f.priorities = append(f.priorities, p)
+			}
+		case "has":
+			if exclude {
+				return nil, fmt.Errorf("cannot exclude has: terms")
+			}
+			for _, v := range values {
+				switch strings.ToLower(v) {
+				case "deadline":
+					f.requireDeadline = true
+				case "scheduled":
+					f.requireScheduled = true
+				default:
+					return nil, fmt.Errorf("unknown has: value: %s", v)
+				}
+			}
+		case "file":
+			if exclude {
+				return nil, fmt.Errorf("cannot exclude files")
+			}
+			f.files = append(f.files, values...)
+		default:
+			return nil, fmt.Errorf("unknown filter term: %s", key)
+		}
+	}
+
+	return f, nil
+}
+
+// splitFilterValues splits a comma-separated filter value, dropping empty parts
+func splitFilterValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.Trim(strings.TrimSpace(v), ":")
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// matches returns true if the item itself satisfies every term of the filter
+func (f *itemFilter) matches(item *model.Item) bool {
+	if len(f.tags) > 0 && !hasAnyTag(item, f.tags) {
+		return false
+	}
+	if len(f.excludeTags) > 0 && hasAnyTag(item, f.excludeTags) {
+		return false
+	}
+	if len(f.states) > 0 && !containsString(f.states, string(item.State)) {
+		return false
+	}
+	if len(f.excludeStates) > 0 && containsString(f.excludeStates, string(item.State)) {
+		return false
+	}
+	if len(f.priorities) > 0 {
+		found := false
+		for _, p := range f.priorities {
+			if item.Priority == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.requireDeadline && item.Deadline == nil {
+		return false
+	}
+	if f.requireScheduled && item.Scheduled == nil {
+		return false
+	}
+	if len(f.files) > 0 {
+		if item.SourceFile == "" {
+			return false
+		}
+		base := filepath.Base(item.SourceFile)
+		found := false
+		for _, file := range f.files {
+			// Files in subdirectories can also be given by their path, as in projects/work.org
+			if base == file || strings.TrimSuffix(base, filepath.Ext(base)) == file ||
+				strings.HasSuffix(filepath.ToSlash(item.SourceFile), "/"+strings.TrimPrefix(file, "/")) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	title := strings.ToLower(item.Title)
+	for _, word := range f.text {
+		if !strings.Contains(title, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the matching items along with their ancestors for context.
+// Folding is ignored on the path to a match so matches are never hidden.
+func (f *itemFilter) apply(roots []*model.Item) []*model.Item {
+	var collect func([]*model.Item) []*model.Item
+	collect = func(list []*model.Item) []*model.Item {
+		var visible []*model.Item
+		for _, item := range list {
+			descendants := collect(item.Children)
+			if f.matches(item) || len(descendants) > 0 {
+				visible = append(visible, item)
+				visible = append(visible, descendants...)
+			}
+		}
+		return visible
+	}
+	return collect(roots)
+}
+
+// hasAnyTag returns true if the item has at least one of the tags
+func hasAnyTag(item *model.Item, tags []string) bool {
+	for _, tag := range item.Tags {
+		if containsString(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString returns true if the slice contains the value
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// narrowTo restricts the list view to the subtree of the given item
+func (m *uiModel) narrowTo(item *model.Item) {
+	m.narrowRoot = item
+	item.Folded = false
+	m.cursor = 0
+	m.scrollOffset = 0
+}
+
+// widen removes any narrowing, keeping the cursor on the previously narrowed item
+func (m *uiModel) widen() {
+	root := m.narrowRoot
+	m.narrowRoot = nil
+	m.scrollOffset = 0
+	m.cursor = 0
+	for i, item := range m.getVisibleItems() {
+		if item == root {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// containsItem returns true if the target is somewhere in the tree of items
+func containsItem(items []*model.Item, target *model.Item) bool {
+	for _, item := range items {
+		if item == target || containsItem(item.Children, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterStatus describes the active filter and narrowing for the status bar
+func (m uiModel) filterStatus() string {
+	var parts []string
+	if m.narrowRoot != nil {
+		parts = append(parts, fmt.Sprintf("Narrowed: %s", m.narrowRoot.Title))
+	}
+	if m.filter != nil {
+		parts = append(parts, fmt.Sprintf("Filter: %s", m.filter.query))
+	}
+	return strings.Join(parts, " • ")
+}
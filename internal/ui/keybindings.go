@@ -37,6 +37,28 @@ type keyMap struct {
 	SetEffort     key.Binding
 	Settings      key.Binding
 	TagItem       key.Binding
+	ToggleMark    key.Binding
+	ToggleVisual  key.Binding
+	ClearMarks    key.Binding
+	Filter        key.Binding
+	ClearFilter   key.Binding
+	Narrow        key.Binding
+	Widen         key.Binding
+	SortItems     key.Binding
+	EffortReport  key.Binding
+	ColumnView    key.Binding
+	AssignID      key.Binding
+	FollowLink    key.Binding
+	InsertLink    key.Binding
+	EditTable     key.Binding
+	ExecuteBlock  key.Binding
+	EditExternal  key.Binding
+	EditSubtree   key.Binding
+	NewFile       key.Binding
+	MoveToFile    key.Binding
+	JumpToClock   key.Binding
+	ClockHistory  key.Binding
+	Pomodoro      key.Binding
 }
 
 // newKeyMapFromConfig creates a keyMap from configuration
@@ -160,6 +182,94 @@ func newKeyMapFromConfig(cfg *config.Config) keyMap {
 			key.WithKeys(kb.TagItem...),
 			key.WithHelp(formatKeyHelp(kb.TagItem), "add/edit tags"),
 		),
+		ToggleMark: key.NewBinding(
+			key.WithKeys(kb.ToggleMark...),
+			key.WithHelp(formatKeyHelp(kb.ToggleMark), "mark/unmark item"),
+		),
+		ToggleVisual: key.NewBinding(
+			key.WithKeys(kb.ToggleVisual...),
+			key.WithHelp(formatKeyHelp(kb.ToggleVisual), "visual range select"),
+		),
+		ClearMarks: key.NewBinding(
+			key.WithKeys(kb.ClearMarks...),
+			key.WithHelp(formatKeyHelp(kb.ClearMarks), "clear marks"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys(kb.Filter...),
+			key.WithHelp(formatKeyHelp(kb.Filter), "filter items"),
+		),
+		ClearFilter: key.NewBinding(
+			key.WithKeys(kb.ClearFilter...),
+			key.WithHelp(formatKeyHelp(kb.ClearFilter), "clear filter"),
+		),
+		Narrow: key.NewBinding(
+			key.WithKeys(kb.Narrow...),
+			key.WithHelp(formatKeyHelp(kb.Narrow), "narrow to subtree"),
+		),
+		Widen: key.NewBinding(
+			key.WithKeys(kb.Widen...),
+			key.WithHelp(formatKeyHelp(kb.Widen), "widen (undo narrow)"),
+		),
+		SortItems: key.NewBinding(
+			key.WithKeys(kb.SortItems...),
+			key.WithHelp(formatKeyHelp(kb.SortItems), "sort children"),
+		),
+		EffortReport: key.NewBinding(
+			key.WithKeys(kb.EffortReport...),
+			key.WithHelp(formatKeyHelp(kb.EffortReport), "effort report"),
+		),
+		ColumnView: key.NewBinding(
+			key.WithKeys(kb.ColumnView...),
+			key.WithHelp(formatKeyHelp(kb.ColumnView), "column view"),
+		),
+		AssignID: key.NewBinding(
+			key.WithKeys(kb.AssignID...),
+			key.WithHelp(formatKeyHelp(kb.AssignID), "assign ID"),
+		),
+		FollowLink: key.NewBinding(
+			key.WithKeys(kb.FollowLink...),
+			key.WithHelp(formatKeyHelp(kb.FollowLink), "follow link"),
+		),
+		InsertLink: key.NewBinding(
+			key.WithKeys(kb.InsertLink...),
+			key.WithHelp(formatKeyHelp(kb.InsertLink), "insert link"),
+		),
+		EditTable: key.NewBinding(
+			key.WithKeys(kb.EditTable...),
+			key.WithHelp(formatKeyHelp(kb.EditTable), "edit table"),
+		),
+		ExecuteBlock: key.NewBinding(
+			key.WithKeys(kb.ExecuteBlock...),
+			key.WithHelp(formatKeyHelp(kb.ExecuteBlock), "run source block"),
+		),
+		EditExternal: key.NewBinding(
+			key.WithKeys(kb.EditExternal...),
+			key.WithHelp(formatKeyHelp(kb.EditExternal), "edit notes in $EDITOR"),
+		),
+		EditSubtree: key.NewBinding(
+			key.WithKeys(kb.EditSubtree...),
+			key.WithHelp(formatKeyHelp(kb.EditSubtree), "edit subtree in $EDITOR"),
+		),
+		NewFile: key.NewBinding(
+			key.WithKeys(kb.NewFile...),
+			key.WithHelp(formatKeyHelp(kb.NewFile), "new file"),
+		),
+		MoveToFile: key.NewBinding(
+			key.WithKeys(kb.MoveToFile...),
+			key.WithHelp(formatKeyHelp(kb.MoveToFile), "move to file"),
+		),
+		JumpToClock: key.NewBinding(
+			key.WithKeys(kb.JumpToClock...),
+			key.WithHelp(formatKeyHelp(kb.JumpToClock), "jump to clock"),
+		),
+		ClockHistory: key.NewBinding(
+			key.WithKeys(kb.ClockHistory...),
+			key.WithHelp(formatKeyHelp(kb.ClockHistory), "clock history"),
+		),
+		Pomodoro: key.NewBinding(
+			key.WithKeys(kb.Pomodoro...),
+			key.WithHelp(formatKeyHelp(kb.Pomodoro), "pomodoro"),
+		),
 	}
 }
 
@@ -203,9 +313,12 @@ func (k keyMap) FullHelp() [][]key.Binding {
 func (k keyMap) getAllBindings() []key.Binding {
 	return []key.Binding{
 		k.Up, k.Down, k.Left, k.Right,
-		k.ToggleFold, k.ToggleFoldAll, k.EditNotes, k.ToggleReorder,
-		k.Capture, k.AddSubTask, k.Delete, k.Save,
-		k.ClockIn, k.ClockOut, k.SetDeadline, k.SetScheduled, k.SetPriority, k.SetEffort,
-		k.TagItem, k.Settings, k.ToggleView, k.Help, k.Quit,
+		k.ToggleFold, k.ToggleFoldAll, k.EditNotes, k.EditExternal, k.EditSubtree, k.EditTable, k.ExecuteBlock, k.ToggleReorder,
+		k.Capture, k.AddSubTask, k.Delete, k.NewFile, k.MoveToFile, k.Save,
+		k.ClockIn, k.ClockOut, k.JumpToClock, k.ClockHistory, k.Pomodoro, k.SetDeadline, k.SetScheduled, k.SetPriority, k.SetEffort, k.EffortReport,
+		k.TagItem, k.ToggleMark, k.ToggleVisual, k.ClearMarks,
+		k.Filter, k.ClearFilter, k.Narrow, k.Widen, k.SortItems,
+		k.FollowLink, k.InsertLink, k.AssignID,
+		k.Settings, k.ToggleView, k.ColumnView, k.Help, k.Quit,
 	}
 }
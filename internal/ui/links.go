@@ -0,0 +1,621 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// orgLink is a [[target][description]] link found in an item's notes
+type orgLink struct {
+	target      string
+	description string
+}
+
+var linkPattern = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+
+// parseLinks returns the links in the given lines, in order
+func parseLinks(lines []string) []orgLink {
+	var links []orgLink
+	for _, line := range lines {
+		for _, matches := range linkPattern.FindAllStringSubmatch(line, -1) {
+			links = append(links, orgLink{target: matches[1], description: matches[2]})
+		}
+	}
+	return links
+}
+
+// Links are protected from word wrapping by encoding them as a single word
+// with private-use markers; renderInline and restoreLinks decode them again
+const (
+	linkStartMarker = string(model.ProtectStart)
+	linkSepMarker   = "\uE002"
+	linkEndMarker   = string(model.ProtectEnd)
+	linkSpaceMarker = string(model.ProtectSpace)
+)
+
+var protectedLinkPattern = regexp.MustCompile(linkStartMarker + `([^` + linkSepMarker + `]*)` + linkSepMarker + `([^` + linkEndMarker + `]*)` + linkEndMarker)
+
+// protectLinks encodes the links in a line so word wrapping keeps each one on a single line
+func protectLinks(line string) string {
+	return linkPattern.ReplaceAllStringFunc(line, func(raw string) string {
+		matches := linkPattern.FindStringSubmatch(raw)
+		encode := func(s string) string { return strings.ReplaceAll(s, " ", linkSpaceMarker) }
+		return linkStartMarker + encode(matches[1]) + linkSepMarker + encode(matches[2]) + linkEndMarker
+	})
+}
+
+// decodeProtectedLinks replaces each protected link using the given function and
+// drops any markers left over from links that were split across lines
+func decodeProtectedLinks(line string, replace func(orgLink) string) string {
+	line = protectedLinkPattern.ReplaceAllStringFunc(line, func(raw string) string {
+		matches := protectedLinkPattern.FindStringSubmatch(raw)
+		decode := func(s string) string { return strings.ReplaceAll(s, linkSpaceMarker, " ") }
+		return replace(orgLink{target: decode(matches[1]), description: decode(matches[2])})
+	})
+	for _, marker := range []string{linkStartMarker, linkSepMarker, linkEndMarker} {
+		line = strings.ReplaceAll(line, marker, "")
+	}
+	return strings.ReplaceAll(line, linkSpaceMarker, " ")
+}
+
+// restoreLinks turns protected links back into org link syntax
+func restoreLinks(line string) string {
+	return decodeProtectedLinks(line, orgLink.String)
+}
+
+// hasProtectedLinks returns true if a line contains protected links
+func hasProtectedLinks(line string) bool {
+	return strings.Contains(line, linkStartMarker)
+}
+
+// label returns the text shown for a link: its description, or its target
+func (l orgLink) label() string {
+	if l.description != "" {
+		return l.description
+	}
+	return l.target
+}
+
+// startFollowLink follows the link in the current item's notes, or opens a
+// picker if there are several
+func (m *uiModel) startFollowLink() tea.Cmd {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return nil
+	}
+	links := parseLinks(items[m.cursor].Notes)
+	switch len(links) {
+	case 0:
+		m.setStatus("No links in this item")
+	case 1:
+		return m.followLink(links[0])
+	default:
+		m.links = links
+		m.linkCursor = 0
+		m.mode = modeLinkSelect
+	}
+	return nil
+}
+
+// followLink navigates to the target of a link. Links to headings, IDs and
+// loaded org files are followed inside the app; anything else is passed to
+// the configured opener command.
+func (m *uiModel) followLink(link orgLink) tea.Cmd {
+	target := link.target
+
+	if id, ok := strings.CutPrefix(target, "id:"); ok {
+		item := m.orgFile.FindByID(id)
+		if item == nil {
+			m.setStatus(fmt.Sprintf("No item with ID %s", id))
+			return nil
+		}
+		m.jumpToItem(item)
+		return nil
+	}
+
+	// Links within the current file: [[*Heading]], [[#custom-id]] or [[Heading]]
+	if !strings.Contains(target, ":") {
+		m.followSearchLink(m.currentFileItems(), target)
+		return nil
+	}
+
+	if path, ok := strings.CutPrefix(target, "file:"); ok {
+		path, search, _ := strings.Cut(path, "::")
+		path = m.resolveLinkPath(path)
+		if items, loaded := m.loadedFileItems(path); loaded {
+			if search == "" {
+				if len(items) > 0 {
+					m.jumpToItem(items[0])
+				} else {
+					m.setStatus(fmt.Sprintf("%s has no headings", filepath.Base(path)))
+				}
+				return nil
+			}
+			m.followSearchLink(items, search)
+			return nil
+		}
+		return m.openExternal(path)
+	}
+
+	return m.openExternal(target)
+}
+
+// followSearchLink jumps to the heading matching an org search option:
+// "*Heading" matches a title, "#id" a CUSTOM_ID property, and plain text
+// matches a title exactly or, failing that, as a substring
+func (m *uiModel) followSearchLink(items []*model.Item, search string) {
+	all := model.FlattenAllItems(items)
+	var match *model.Item
+
+	switch {
+	case strings.HasPrefix(search, "*"):
+		title := strings.TrimSpace(strings.TrimPrefix(search, "*"))
+		for _, item := range all {
+			if strings.EqualFold(item.Title, title) {
+				match = item
+				break
+			}
+		}
+	case strings.HasPrefix(search, "#"):
+		customID := strings.TrimPrefix(search, "#")
+		for _, item := range all {
+			if item.GetProperty("CUSTOM_ID") == customID {
+				match = item
+				break
+			}
+		}
+	default:
+		for _, item := range all {
+			if strings.EqualFold(item.Title, search) {
+				match = item
+				break
+			}
+		}
+		if match == nil {
+			lower := strings.ToLower(search)
+			for _, item := range all {
+				if strings.Contains(strings.ToLower(item.Title), lower) {
+					match = item
+					break
+				}
+			}
+		}
+	}
+
+	if match == nil {
+		m.setStatus(fmt.Sprintf("No heading matches %s", search))
+		return
+	}
+	m.jumpToItem(match)
+}
+
+// currentFileItems returns the items of the file the cursor is in
+func (m uiModel) currentFileItems() []*model.Item {
+	items := m.getVisibleItems()
+	if m.cursor < len(items) && items[m.cursor].SourceFile != "" {
+		if fileItems, ok := m.loadedFileItems(items[m.cursor].SourceFile); ok {
+			return fileItems
+		}
+	}
+	return m.orgFile.Items
+}
+
+// currentFilePath returns the path of the file the cursor is in
+func (m uiModel) currentFilePath() string {
+	items := m.getVisibleItems()
+	if m.cursor < len(items) && items[m.cursor].SourceFile != "" {
+		return items[m.cursor].SourceFile
+	}
+	return m.orgFile.Path
+}
+
+// resolveLinkPath resolves a file link relative to the directory of the current file
+func (m uiModel) resolveLinkPath(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(m.currentFilePath()), path)
+	}
+	return filepath.Clean(path)
+}
+
+// loadedFileItems returns the top-level items of a file if it is loaded in the app
+func (m uiModel) loadedFileItems(path string) ([]*model.Item, bool) {
+	for _, fileItem := range model.FileItems(m.orgFile.Items) {
+		if sameFile(fileItem.SourceFile, path) {
+			return fileItem.Children, true
+		}
+	}
+	if m.orgFile.Path != "" && sameFile(m.orgFile.Path, path) {
+		return m.orgFile.Items, true
+	}
+	return nil, false
+}
+
+// sameFile returns true if two paths refer to the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// linkOpenedMsg reports the result of opening a link with the external opener
+type linkOpenedMsg struct {
+	target string
+	err    error
+}
+
+// openExternal opens a URL or file with the configured opener command
+func (m *uiModel) openExternal(target string) tea.Cmd {
+	opener := strings.Fields(m.config.Links.Opener)
+	if len(opener) == 0 {
+		switch runtime.GOOS {
+		case "darwin":
+			opener = []string{"open"}
+		case "windows":
+			opener = []string{"rundll32", "url.dll,FileProtocolHandler"}
+		default:
+			opener = []string{"xdg-open"}
+		}
+	}
+	m.setStatus(fmt.Sprintf("Opening %s", target))
+
+	args := append(opener[1:], target)
+	return func() tea.Msg {
+		err := exec.Command(opener[0], args...).Run()
+		return linkOpenedMsg{target: target, err: err}
+	}
+}
+
+// jumpToItem moves the cursor to an item anywhere in the tree, unfolding its
+// ancestors and widening or clearing the filter if they would hide it
+func (m *uiModel) jumpToItem(target *model.Item) {
+	m.mode = modeList
+	if m.narrowRoot != nil && !containsItem([]*model.Item{m.narrowRoot}, target) {
+		m.narrowRoot = nil
+	}
+	for parent := m.findParent(target); parent != nil; parent = m.findParent(parent) {
+		parent.Folded = false
+	}
+
+	index := m.visibleIndex(target)
+	if index < 0 && m.filter != nil {
+		m.filter = nil
+		index = m.visibleIndex(target)
+	}
+	if index < 0 {
+		m.setStatus(fmt.Sprintf("Cannot show \"%s\"", target.Title))
+		return
+	}
+
+	m.cursor = index
+	availableHeight := m.height - 6 // Approximate
+	if availableHeight < 5 {
+		availableHeight = 5
+	}
+	m.updateScrollOffset(availableHeight)
+
+	msg := fmt.Sprintf("Jumped to \"%s\"", target.Title)
+	if target.SourceFile != "" {
+		msg = fmt.Sprintf("%s in %s", msg, filepath.Base(target.SourceFile))
+	}
+	m.setStatus(msg)
+}
+
+// visibleIndex returns the index of an item in the visible list, or -1
+func (m uiModel) visibleIndex(target *model.Item) int {
+	for i, item := range m.getVisibleItems() {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// assignIDs gives each action target an :ID: property if it has none
+func (m *uiModel) assignIDs() {
+	targets := m.actionTargets()
+	if len(targets) == 0 {
+		return
+	}
+	created := 0
+	var id string
+	for _, item := range targets {
+		var isNew bool
+		id, isNew = item.EnsureID()
+		if isNew {
+			created++
+		}
+	}
+	m.orgFile.BuildIDIndex()
+
+	if len(targets) == 1 {
+		if created == 0 {
+			m.setStatus(fmt.Sprintf("ID: %s", id))
+		} else {
+			m.reportBulkAction(1, fmt.Sprintf("ID assigned: %s", id))
+		}
+		return
+	}
+	m.reportBulkAction(len(targets), fmt.Sprintf("%d IDs assigned", created))
+}
+
+// updateLinkSelect handles key presses in the link picker
+func (m uiModel) updateLinkSelect(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.mode = modeList
+			m.links = nil
+			m.setStatus("Cancelled")
+		case "up", "k":
+			if m.linkCursor > 0 {
+				m.linkCursor--
+			}
+		case "down", "j":
+			if m.linkCursor < len(m.links)-1 {
+				m.linkCursor++
+			}
+		case "enter":
+			link := m.links[m.linkCursor]
+			m.mode = modeList
+			m.links = nil
+			return m, m.followLink(link)
+		default:
+			// Number keys pick a link directly
+			if s := msg.String(); len(s) == 1 && s[0] >= '1' && s[0] <= '9' {
+				if i := int(s[0] - '1'); i < len(m.links) {
+					link := m.links[i]
+					m.mode = modeList
+					m.links = nil
+					return m, m.followLink(link)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// viewLinkSelect renders the link picker
+func (m uiModel) viewLinkSelect() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("99")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Follow Link"))
+	content.WriteString("\n\n")
+
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99")).Bold(true)
+	for i, link := range m.links {
+		line := fmt.Sprintf("%s %s", keyStyle.Render(fmt.Sprintf("[%d]", i+1)), link.label())
+		if link.description != "" {
+			line += " " + m.styles.statusStyle.Render(link.target)
+		}
+		if i == m.linkCursor {
+			line = m.styles.cursorStyle.Render(line)
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("↑/↓ or 1-9 to choose • Enter to follow • ESC to cancel"))
+
+	dialog := dialogStyle.Render(content.String())
+
+	// Center the dialog horizontally and vertically
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// linkSchemes are the link prefixes treated as external targets in the insertion prompt
+var linkSchemes = []string{"http://", "https://", "file:", "id:", "mailto:", "ftp://"}
+
+// startInsertLink opens the link insertion prompt for the item under the cursor
+func (m *uiModel) startInsertLink() tea.Cmd {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return nil
+	}
+	m.editingItem = items[m.cursor]
+	m.linkCursor = 0
+	m.mode = modeInsertLink
+	m.textinput.SetValue("")
+	m.textinput.Placeholder = "Heading, or URL followed by a description"
+	m.textinput.Focus()
+	return textinput.Blink
+}
+
+// isExternalLinkInput returns true if the prompt input is a URL or explicit link target
+func isExternalLinkInput(input string) bool {
+	for _, scheme := range linkSchemes {
+		if strings.HasPrefix(input, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkCompletions returns the headings whose titles contain the query, for the insertion prompt
+func (m uiModel) linkCompletions(query string) []*model.Item {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || isExternalLinkInput(query) {
+		return nil
+	}
+
+	var matches []*model.Item
+	for _, item := range model.FlattenAllItems(m.orgFile.Items) {
+		// File and directory wrappers in multi-file mode are not headings
+		if item == m.editingItem || item.IsWrapper() {
+			continue
+		}
+		if strings.Contains(strings.ToLower(item.Title), query) {
+			matches = append(matches, item)
+			if len(matches) == 8 {
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// headingLink builds a link to a heading: an id: link if it has an ID, otherwise
+// a *Heading search link, with a file: path if it is in another file
+func (m uiModel) headingLink(from, to *model.Item) orgLink {
+	if id := to.GetProperty("ID"); id != "" {
+		return orgLink{target: "id:" + id, description: to.Title}
+	}
+	if to.SourceFile != "" && from.SourceFile != to.SourceFile {
+		path := to.SourceFile
+		if rel, err := filepath.Rel(filepath.Dir(from.SourceFile), to.SourceFile); err == nil {
+			path = rel
+		}
+		return orgLink{target: fmt.Sprintf("file:%s::*%s", path, to.Title), description: to.Title}
+	}
+	return orgLink{target: "*" + to.Title, description: to.Title}
+}
+
+// String formats the link in org syntax
+func (l orgLink) String() string {
+	if l.description == "" {
+		return fmt.Sprintf("[[%s]]", l.target)
+	}
+	return fmt.Sprintf("[[%s][%s]]", l.target, l.description)
+}
+
+// updateInsertLink handles the link insertion prompt
+func (m uiModel) updateInsertLink(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.textinput.Width = 50
+
+	case tea.KeyMsg:
+		completions := m.linkCompletions(m.textinput.Value())
+		switch msg.Type {
+		case tea.KeyUp:
+			if m.linkCursor > 0 {
+				m.linkCursor--
+			}
+			return m, nil
+		case tea.KeyDown:
+			if m.linkCursor < len(completions)-1 {
+				m.linkCursor++
+			}
+			return m, nil
+		case tea.KeyTab:
+			// Complete the input to the selected heading
+			if m.linkCursor < len(completions) {
+				m.textinput.SetValue(completions[m.linkCursor].Title)
+				m.textinput.CursorEnd()
+				m.linkCursor = 0
+			}
+			return m, nil
+		case tea.KeyEnter:
+			input := strings.TrimSpace(m.textinput.Value())
+			var link orgLink
+			switch {
+			case input == "":
+				m.setStatus("Cancelled")
+			case isExternalLinkInput(input):
+				target, description, _ := strings.Cut(input, " ")
+				link = orgLink{target: target, description: strings.TrimSpace(description)}
+			case m.linkCursor < len(completions):
+				link = m.headingLink(m.editingItem, completions[m.linkCursor])
+			default:
+				m.setStatus(fmt.Sprintf("No heading matches %s", input))
+				return m, nil
+			}
+			if link.target != "" && m.editingItem != nil {
+				m.editingItem.Notes = append(m.editingItem.Notes, link.String())
+				m.setStatus(fmt.Sprintf("Link to %s inserted", link.label()))
+			}
+			m.mode = modeList
+			m.textinput.Blur()
+			m.editingItem = nil
+			return m, nil
+		case tea.KeyEsc:
+			m.mode = modeList
+			m.textinput.Blur()
+			m.editingItem = nil
+			m.setStatus("Cancelled")
+			return m, nil
+		}
+	}
+
+	m.textinput, cmd = m.textinput.Update(msg)
+	// Typing changes the completions, so reset the selection
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.linkCursor = 0
+	}
+	return m, cmd
+}
+
+// viewInsertLink renders the link insertion prompt with heading completions
+func (m uiModel) viewInsertLink() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("99")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Insert Link"))
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Into: %s", m.promptTargetLabel())))
+	content.WriteString("\n\n")
+	content.WriteString(m.textinput.View())
+	content.WriteString("\n\n")
+
+	completions := m.linkCompletions(m.textinput.Value())
+	for i, item := range completions {
+		line := item.Title
+		if item.SourceFile != "" {
+			line += " " + m.styles.statusStyle.Render(filepath.Base(item.SourceFile))
+		}
+		if i == m.linkCursor {
+			line = m.styles.cursorStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+	if len(completions) > 0 {
+		content.WriteString("\n")
+	}
+
+	content.WriteString(m.styles.statusStyle.Render("Examples: Project plan, https://example.com Docs"))
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("↑/↓ choose • Tab complete • Enter insert • ESC cancel"))
+
+	dialog := dialogStyle.Render(content.String())
+
+	// Center the dialog horizontally and vertically
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
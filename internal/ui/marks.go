@@ -0,0 +1,250 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// toggleMark marks or unmarks the item under the cursor and advances the cursor
+func (m *uiModel) toggleMark() {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return
+	}
+
+	// In visual mode, marking commits the selected range
+	if m.visualMode {
+		m.commitVisualRange()
+		return
+	}
+
+	item := items[m.cursor]
+	if m.marked[item] {
+		delete(m.marked, item)
+	} else {
+		m.marked[item] = true
+	}
+
+	// Advance the cursor so several items can be marked in a row
+	if m.cursor < len(items)-1 {
+		m.cursor++
+	}
+}
+
+// toggleVisual starts a visual range selection, or commits the current one to marks
+func (m *uiModel) toggleVisual() {
+	if m.visualMode {
+		m.commitVisualRange()
+		return
+	}
+	m.visualMode = true
+	m.visualAnchor = m.cursor
+	m.setStatus("Visual selection ON - move to extend, 'v' or 'm' to mark range")
+}
+
+// commitVisualRange adds every item in the visual range to the marks and leaves visual mode
+func (m *uiModel) commitVisualRange() {
+	items := m.getVisibleItems()
+	start, end := m.visualRange()
+	for i := start; i <= end && i < len(items); i++ {
+		m.marked[items[i]] = true
+	}
+	m.visualMode = false
+	m.setStatus("Range marked")
+}
+
+// visualRange returns the inclusive bounds of the visual selection
+func (m uiModel) visualRange() (int, int) {
+	if m.visualAnchor < m.cursor {
+		return m.visualAnchor, m.cursor
+	}
+	return m.cursor, m.visualAnchor
+}
+
+// clearSelection removes all marks and ends any visual selection
+func (m *uiModel) clearSelection() {
+	m.marked = make(map[*model.Item]bool)
+	m.visualMode = false
+}
+
+// hasSelection returns true if items are marked or a visual range is active
+func (m uiModel) hasSelection() bool {
+	return len(m.marked) > 0 || m.visualMode
+}
+
+// isSelected returns true if the item at the given visible index is marked or in the visual range
+func (m uiModel) isSelected(item *model.Item, index int) bool {
+	if m.marked[item] {
+		return true
+	}
+	if m.visualMode {
+		start, end := m.visualRange()
+		return index >= start && index <= end
+	}
+	return false
+}
+
+// selectedItems returns the marked items and visual range in document order
+func (m uiModel) selectedItems() []*model.Item {
+	selected := make(map[*model.Item]bool)
+	for item := range m.marked {
+		selected[item] = true
+	}
+	if m.visualMode {
+		items := m.getVisibleItems()
+		start, end := m.visualRange()
+		for i := start; i <= end && i < len(items); i++ {
+			selected[items[i]] = true
+		}
+	}
+
+	// Walk the whole tree (ignoring folds) so the order is stable
+	var result []*model.Item
+	var walk func([]*model.Item)
+	walk = func(list []*model.Item) {
+		for _, item := range list {
+			if selected[item] {
+				result = append(result, item)
+			}
+			walk(item.Children)
+		}
+	}
+	walk(m.orgFile.Items)
+	return result
+}
+
+// actionTargets returns the items an action applies to: the selection if any, otherwise the cursor item
+func (m uiModel) actionTargets() []*model.Item {
+	if m.hasSelection() {
+		return m.selectedItems()
+	}
+	items := m.getVisibleItems()
+	if len(items) > 0 && m.cursor < len(items) {
+		return []*model.Item{items[m.cursor]}
+	}
+	return nil
+}
+
+// promptTargets returns the items a prompt applies to once confirmed
+func (m uiModel) promptTargets() []*model.Item {
+	if len(m.bulkItems) > 0 {
+		return m.bulkItems
+	}
+	if m.editingItem != nil {
+		return []*model.Item{m.editingItem}
+	}
+	return nil
+}
+
+// startPrompt records the targets for a prompt, returning false if there is nothing to act on
+func (m *uiModel) startPrompt() bool {
+	targets := m.actionTargets()
+	if len(targets) == 0 {
+		return false
+	}
+	m.editingItem = targets[0]
+	m.bulkItems = nil
+	if len(targets) > 1 {
+		m.bulkItems = targets
+	}
+	return true
+}
+
+// finishPrompt resets prompt targets, clearing the selection if the action consumed it
+func (m *uiModel) finishPrompt(applied bool) {
+	if applied && m.hasSelection() {
+		m.clearSelection()
+	}
+	m.bulkItems = nil
+	m.editingItem = nil
+}
+
+// applyTagInput updates an item's tags from colon-separated input.
+// Tags prefixed with + are added and tags prefixed with - are removed;
+// input without any prefixes replaces the tags entirely.
+func applyTagInput(item *model.Item, input string) {
+	var tokens []string
+	for _, tag := range strings.FieldsFunc(input, func(r rune) bool { return r == ':' || r == ' ' }) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tokens = append(tokens, tag)
+		}
+	}
+
+	isDelta := false
+	for _, token := range tokens {
+		if strings.HasPrefix(token, "+") || strings.HasPrefix(token, "-") {
+			isDelta = true
+			break
+		}
+	}
+
+	if !isDelta {
+		item.Tags = tokens
+		return
+	}
+
+	for _, token := range tokens {
+		if strings.HasPrefix(token, "-") {
+			name := strings.TrimPrefix(token, "-")
+			var kept []string
+			for _, tag := range item.Tags {
+				if tag != name {
+					kept = append(kept, tag)
+				}
+			}
+			item.Tags = kept
+			continue
+		}
+
+		name := strings.TrimPrefix(token, "+")
+		if name == "" {
+			continue
+		}
+		exists := false
+		for _, tag := range item.Tags {
+			if tag == name {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			item.Tags = append(item.Tags, name)
+		}
+	}
+}
+
+// reportBulkAction sets the status after an immediate action and clears any selection it consumed
+func (m *uiModel) reportBulkAction(count int, msg string) {
+	if count == 0 {
+		return
+	}
+	if count > 1 {
+		msg = fmt.Sprintf("%s (%d items)", msg, count)
+	}
+	if m.hasSelection() {
+		m.clearSelection()
+	}
+	m.setStatus(msg)
+}
+
+// reportBulkPrompt sets the status after a prompt has been applied to its targets
+func (m *uiModel) reportBulkPrompt(count int, msg string) {
+	if count > 1 {
+		msg = fmt.Sprintf("%s (%d items)", msg, count)
+	}
+	m.setStatus(msg)
+}
+
+// promptTargetLabel describes the targets of the current prompt for dialog headers
+func (m uiModel) promptTargetLabel() string {
+	if len(m.bulkItems) > 0 {
+		return fmt.Sprintf("%d selected items", len(m.bulkItems))
+	}
+	if m.editingItem != nil {
+		return m.editingItem.Title
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// hasEmphasis returns true if the text contains org inline markup
+func hasEmphasis(text string) bool {
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		i = model.SkipProtected(runes, i)
+		if model.FindEmphasis(runes, i) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// protectEmphasis encodes the spaces in emphasized text no wider than width so word
+// wrapping keeps it on a single line; longer emphasis is left to wrap normally
+func protectEmphasis(line string, width int) string {
+	if !strings.ContainsAny(line, model.EmphasisMarkers) {
+		return line
+	}
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		i = model.SkipProtected(runes, i)
+		end := model.FindEmphasis(runes, i)
+		if end < 0 {
+			continue
+		}
+		if end-i+1 <= width {
+			for j := i + 1; j < end; j++ {
+				if runes[j] == ' ' {
+					runes[j] = []rune(linkSpaceMarker)[0]
+				}
+			}
+		}
+		i = end
+	}
+	return string(runes)
+}
+
+// emphasisStyle returns the style for text emphasized with the given marker
+func (m uiModel) emphasisStyle(marker rune, base lipgloss.Style) lipgloss.Style {
+	switch marker {
+	case '*':
+		return base.Bold(true)
+	case '/':
+		return base.Italic(true)
+	case '_':
+		return base.Underline(true)
+	case '+':
+		return base.Strikethrough(true)
+	default: // = and ~
+		return m.styles.codeStyle.Inherit(base)
+	}
+}
+
+// renderInline renders a line with its links shown as their descriptions and, when
+// org syntax highlighting is enabled, its emphasis markup styled with the markers hidden
+func (m uiModel) renderInline(text string) string {
+	return m.renderInlineRunes([]rune(text), lipgloss.NewStyle(), false)
+}
+
+// renderInlineStyled is renderInline with the whole line drawn in a base style
+func (m uiModel) renderInlineStyled(text string, base lipgloss.Style) string {
+	return m.renderInlineRunes([]rune(text), base, true)
+}
+
+func (m uiModel) renderInlineRunes(runes []rune, base lipgloss.Style, styled bool) string {
+	var b strings.Builder
+	plainStart := 0
+	for i := 0; i < len(runes); i++ {
+		i = model.SkipProtected(runes, i)
+		if !m.config.UI.OrgSyntaxHighlighting {
+			continue
+		}
+		end := model.FindEmphasis(runes, i)
+		if end < 0 {
+			continue
+		}
+
+		b.WriteString(m.renderPlain(string(runes[plainStart:i]), base, styled))
+		style := m.emphasisStyle(runes[i], base)
+		inner := runes[i+1 : end]
+		if runes[i] == '=' || runes[i] == '~' {
+			// Verbatim and code text is shown as written, without nested markup
+			b.WriteString(style.Render(restoreLinks(string(inner))))
+		} else {
+			b.WriteString(m.renderInlineRunes(inner, style, true))
+		}
+		i = end
+		plainStart = end + 1
+	}
+	b.WriteString(m.renderPlain(string(runes[plainStart:]), base, styled))
+	return b.String()
+}
+
+// renderPlain renders text without emphasis in the base style, showing its
+// protected links as their styled description
+func (m uiModel) renderPlain(text string, base lipgloss.Style, styled bool) string {
+	render := func(s string) string {
+		s = restoreLinks(s)
+		if !styled || s == "" {
+			return s
+		}
+		return base.Render(s)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range protectedLinkPattern.FindAllStringIndex(text, -1) {
+		b.WriteString(render(text[last:loc[0]]))
+		b.WriteString(decodeProtectedLinks(text[loc[0]:loc[1]], func(link orgLink) string {
+			return m.styles.linkStyle.Inherit(base).Render(link.label())
+		}))
+		last = loc[1]
+	}
+	b.WriteString(render(text[last:]))
+	return b.String()
+}
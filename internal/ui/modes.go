@@ -2,11 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
-	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/rwejlgaard/org/internal/model"
@@ -14,6 +14,45 @@ import (
 )
 
 func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Handle results of background commands
+	switch msg := msg.(type) {
+	case linkOpenedMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Failed to open %s: %v", msg.target, msg.err))
+		}
+		return m, nil
+	case srcBlockResultMsg:
+		m.applySrcBlockResult(msg)
+		return m, nil
+	case pomodoroEndMsg:
+		return m, m.endPomodoro(msg)
+	case pomodoroNotifiedMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Notification failed: %v", msg.err))
+		}
+		return m, nil
+	case clockTickMsg:
+		// Nothing changes but the time shown on the running clock
+		return m, clockTick()
+	case editorFinishedMsg:
+		// Time spent in the editor isn't idle time
+		m.lastActivity = time.Now()
+		m.applyExternalEdit(msg)
+		return m, nil
+	case tea.KeyMsg:
+		if m.noteActivity(time.Now()) {
+			// The key is handled before the idle time is asked about
+			next, cmd := m.updateMode(msg)
+			updated := next.(uiModel)
+			updated.startClockIdle()
+			return updated, cmd
+		}
+	}
+	return m.updateMode(msg)
+}
+
+// updateMode handles a message in the current mode
+func (m uiModel) updateMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle special modes
 	switch m.mode {
 	case modeEdit:
@@ -44,6 +83,32 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateTagEdit(msg)
 	case modeRename:
 		return m.updateRename(msg)
+	case modeFilter:
+		return m.updateFilter(msg)
+	case modeSort:
+		return m.updateSort(msg)
+	case modeEffortReport:
+		return m.updateEffortReport(msg)
+	case modeColumns:
+		return m.updateColumns(msg)
+	case modeLinkSelect:
+		return m.updateLinkSelect(msg)
+	case modeInsertLink:
+		return m.updateInsertLink(msg)
+	case modeTableEdit:
+		return m.updateTableEdit(msg)
+	case modeConfirmExecute:
+		return m.updateConfirmExecute(msg)
+	case modeNewFile:
+		return m.updateNewFile(msg)
+	case modeMoveToFile:
+		return m.updateMoveToFile(msg)
+	case modeClockHistory:
+		return m.updateClockHistory(msg)
+	case modeResolveClock:
+		return m.updateResolveClock(msg)
+	case modeClockIdle:
+		return m.updateClockIdle(msg)
 	}
 
 	switch msg := msg.(type) {
@@ -98,27 +163,10 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.Left):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.cycleStateBackward(items[m.cursor])
-				// Auto clock out when changing to DONE
-				if items[m.cursor].State == model.StateDONE && items[m.cursor].IsClockedIn() {
-					items[m.cursor].ClockOut()
-				}
-				m.setStatus("State changed")
-			}
+			m.changeStates(m.actionTargets(), false)
 
 		case key.Matches(msg, m.keys.Right):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.cycleStateForward(items[m.cursor])
-				// Auto clock out when changing to last state (typically DONE)
-				stateNames := m.config.GetStateNames()
-				if len(stateNames) > 0 && string(items[m.cursor].State) == stateNames[len(stateNames)-1] && items[m.cursor].IsClockedIn() {
-					items[m.cursor].ClockOut()
-				}
-				m.setStatus("State changed")
-			}
+			m.changeStates(m.actionTargets(), true)
 
 		case key.Matches(msg, m.keys.ShiftUp):
 			m.moveItemUp()
@@ -133,16 +181,7 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.demoteItem()
 
 		case key.Matches(msg, m.keys.CycleState):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.cycleStateForward(items[m.cursor])
-				// Auto clock out when changing to last state (typically DONE)
-				stateNames := m.config.GetStateNames()
-				if len(stateNames) > 0 && string(items[m.cursor].State) == stateNames[len(stateNames)-1] && items[m.cursor].IsClockedIn() {
-					items[m.cursor].ClockOut()
-				}
-				m.setStatus("State changed")
-			}
+			m.changeStates(m.actionTargets(), true)
 
 		case key.Matches(msg, m.keys.ToggleFold):
 			items := m.getVisibleItems()
@@ -196,25 +235,19 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.EditNotes):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				selectedItem := items[m.cursor]
+			return m, m.startEditNotes()
 
-				// Check if we're in multi-file mode
-				isMultiFile := len(m.orgFile.Items) > 0 && m.orgFile.Items[0].SourceFile != ""
+		case key.Matches(msg, m.keys.EditExternal):
+			return m, m.startExternalEdit(false)
 
-				// Prevent editing notes for top-level file items in multi-file mode
-				if isMultiFile && selectedItem.Level == 1 && selectedItem.SourceFile != "" {
-					m.setStatus("Cannot add notes to file-level items")
-					return m, nil
-				}
+		case key.Matches(msg, m.keys.EditSubtree):
+			return m, m.startExternalEdit(true)
 
-				m.editingItem = selectedItem
-				m.mode = modeEdit
-				m.textarea.SetValue(strings.Join(m.editingItem.Notes, "\n"))
-				m.textarea.Focus()
-				return m, textarea.Blink
-			}
+		case key.Matches(msg, m.keys.EditTable):
+			return m, m.startTableEdit()
+
+		case key.Matches(msg, m.keys.ExecuteBlock):
+			m.startExecuteBlock()
 
 		case key.Matches(msg, m.keys.Settings):
 			m.mode = modeSettings
@@ -222,12 +255,15 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case key.Matches(msg, m.keys.TagItem):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.editingItem = items[m.cursor]
+			if m.startPrompt() {
 				m.mode = modeTagEdit
-				m.textinput.SetValue(strings.Join(items[m.cursor].Tags, ":"))
-				m.textinput.Placeholder = "tag1:tag2:tag3"
+				if len(m.bulkItems) > 0 {
+					m.textinput.SetValue("")
+					m.textinput.Placeholder = "+tag1:-tag2 (add/remove) or tag1:tag2 (replace)"
+				} else {
+					m.textinput.SetValue(strings.Join(m.editingItem.Tags, ":"))
+					m.textinput.Placeholder = "tag1:tag2:tag3"
+				}
 				m.textinput.Focus()
 				return m, textinput.Blink
 			}
@@ -235,10 +271,17 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Rename):
 			items := m.getVisibleItems()
 			if len(items) > 0 && m.cursor < len(items) {
+				if items[m.cursor].Wrapper == model.DirWrapper {
+					m.setStatus("Cannot rename directory items")
+					break
+				}
 				m.editingItem = items[m.cursor]
 				m.mode = modeRename
 				m.textinput.SetValue(items[m.cursor].Title)
 				m.textinput.Placeholder = "Item title"
+				if m.editingItem.Wrapper == model.FileWrapper {
+					m.textinput.Placeholder = "File name"
+				}
 				m.textinput.Focus()
 				return m, textinput.Blink
 			}
@@ -254,6 +297,11 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.AddSubTask):
 			items := m.getVisibleItems()
 			if len(items) > 0 && m.cursor < len(items) {
+				// Directories only hold files, which sub-tasks can't be saved to
+				if items[m.cursor].Wrapper == model.DirWrapper {
+					m.setStatus("Cannot add sub-tasks to directory items")
+					break
+				}
 				m.editingItem = items[m.cursor]
 				m.mode = modeAddSubTask
 				m.textinput.SetValue("")
@@ -263,12 +311,21 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.Delete):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.itemToDelete = items[m.cursor]
+			if m.startPrompt() {
+				if !m.canDelete() {
+					m.finishPrompt(false)
+					break
+				}
+				m.itemToDelete = m.editingItem
 				m.mode = modeConfirmDelete
 			}
 
+		case key.Matches(msg, m.keys.NewFile):
+			return m, m.startNewFile()
+
+		case key.Matches(msg, m.keys.MoveToFile):
+			m.startMoveToFile()
+
 		case key.Matches(msg, m.keys.ToggleView):
 			if m.mode == modeList {
 				m.mode = modeAgenda
@@ -276,9 +333,10 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.mode = modeList
 			}
 			m.cursor = 0
+			m.clearSelection()
 
 		case key.Matches(msg, m.keys.Save):
-			if err := parser.Save(m.orgFile); err != nil {
+			if err := m.save(); err != nil {
 				m.setStatus(fmt.Sprintf("Error saving: %v", err))
 			} else {
 				m.setStatus("Saved!")
@@ -295,10 +353,13 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.ClockIn):
 			items := m.getVisibleItems()
 			if len(items) > 0 && m.cursor < len(items) {
-				if items[m.cursor].ClockIn() {
-					m.setStatus("Clocked in!")
-				} else {
+				item := items[m.cursor]
+				if item.IsClockedIn() {
 					m.setStatus("Already clocked in")
+				} else if previous := m.clockIn(item); previous != nil {
+					m.setStatus(fmt.Sprintf("Clocked out of %s, clocked in!", previous.Title))
+				} else {
+					m.setStatus("Clocked in!")
 				}
 			}
 
@@ -312,10 +373,17 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case key.Matches(msg, m.keys.JumpToClock):
+			m.jumpToClock()
+
+		case key.Matches(msg, m.keys.ClockHistory):
+			m.startClockHistory()
+
+		case key.Matches(msg, m.keys.Pomodoro):
+			return m, m.togglePomodoro()
+
 		case key.Matches(msg, m.keys.SetDeadline):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.editingItem = items[m.cursor]
+			if m.startPrompt() {
 				m.mode = modeSetDeadline
 				m.textinput.SetValue("")
 				m.textinput.Placeholder = "YYYY-MM-DD or +N (days from today)"
@@ -324,9 +392,7 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.SetScheduled):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.editingItem = items[m.cursor]
+			if m.startPrompt() {
 				m.mode = modeSetScheduled
 				m.textinput.SetValue("")
 				m.textinput.Placeholder = "YYYY-MM-DD or +N (days from today)"
@@ -335,20 +401,91 @@ func (m uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.SetPriority):
-			items := m.getVisibleItems()
-			if len(items) > 0 && m.cursor < len(items) {
-				m.editingItem = items[m.cursor]
+			if m.startPrompt() {
 				m.mode = modeSetPriority
 				return m, nil
 			}
 
+		case key.Matches(msg, m.keys.ToggleMark):
+			m.toggleMark()
+
+		case key.Matches(msg, m.keys.ToggleVisual):
+			m.toggleVisual()
+
+		case key.Matches(msg, m.keys.ClearMarks):
+			m.clearSelection()
+			m.setStatus("Marks cleared")
+
+		case key.Matches(msg, m.keys.Filter):
+			m.mode = modeFilter
+			if m.filter != nil {
+				m.textinput.SetValue(m.filter.query)
+			} else {
+				m.textinput.SetValue("")
+			}
+			m.textinput.Placeholder = "e.g., tag:work -state:DONE prio:A,B"
+			m.textinput.Focus()
+			return m, textinput.Blink
+
+		case key.Matches(msg, m.keys.ClearFilter):
+			if m.filter != nil {
+				m.filter = nil
+				m.cursor = 0
+				m.scrollOffset = 0
+				m.setStatus("Filter cleared")
+			}
+
+		case key.Matches(msg, m.keys.Narrow):
+			if m.mode == modeList {
+				items := m.getVisibleItems()
+				if len(items) > 0 && m.cursor < len(items) {
+					if items[m.cursor] == m.narrowRoot {
+						m.widen()
+						m.setStatus("Widened")
+					} else {
+						m.narrowTo(items[m.cursor])
+						m.setStatus("Narrowed to subtree")
+					}
+				}
+			}
+
+		case key.Matches(msg, m.keys.SortItems):
+			if m.mode == modeList {
+				m.startSort()
+			}
+
+		case key.Matches(msg, m.keys.Widen):
+			if m.narrowRoot != nil {
+				m.widen()
+				m.setStatus("Widened")
+			}
+
+		case key.Matches(msg, m.keys.FollowLink):
+			return m, m.startFollowLink()
+
+		case key.Matches(msg, m.keys.InsertLink):
+			return m, m.startInsertLink()
+
+		case key.Matches(msg, m.keys.AssignID):
+			m.assignIDs()
+			return m, nil
+
+		case key.Matches(msg, m.keys.ColumnView):
+			m.startColumnView()
+			return m, nil
+
+		case key.Matches(msg, m.keys.EffortReport):
+			m.mode = modeEffortReport
+			m.reportScroll = 0
+			return m, nil
+
 		case key.Matches(msg, m.keys.SetEffort):
 			items := m.getVisibleItems()
 			if len(items) > 0 && m.cursor < len(items) {
 				m.editingItem = items[m.cursor]
 				m.mode = modeSetEffort
 				m.textinput.SetValue("")
-				m.textinput.Placeholder = "e.g., 8h, 2d, 1w"
+				m.textinput.Placeholder = "e.g., 1:30, 8h, 2d, 1w"
 				m.textinput.Focus()
 				return m, textinput.Blink
 			}
@@ -377,7 +514,7 @@ func (m uiModel) updateEditMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if noteText == "" {
 					m.editingItem.Notes = []string{}
 				} else {
-					m.editingItem.Notes = strings.Split(noteText, "\n")
+					m.editingItem.Notes = model.AlignTables(strings.Split(noteText, "\n"))
 				}
 			}
 			m.mode = modeList
@@ -396,11 +533,45 @@ func (m uiModel) updateConfirmDelete(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y":
-			// Delete the item
-			m.deleteItem(m.itemToDelete)
+			// Delete the item (or every selected item)
+			targets := m.promptTargets()
+			isFile := len(targets) == 1 && targets[0].Wrapper == model.FileWrapper
+			if isFile {
+				// File items are moved to the trash on disk as well, so the
+				// file doesn't come back on the next load. Edits not yet saved
+				// are written first, so the file in the trash has them.
+				path := targets[0].SourceFile
+				err := parser.SaveFile(m.orgFile, targets[0])
+				if err == nil {
+					err = trashFile(path)
+				}
+				if err != nil && !os.IsNotExist(err) {
+					m.mode = modeList
+					m.itemToDelete = nil
+					m.finishPrompt(false)
+					m.setStatus(fmt.Sprintf("Cannot move %s to the trash: %v", path, err))
+					return m, nil
+				}
+				delete(m.orgFile.Saved, path)
+			}
+			for _, item := range targets {
+				m.deleteItem(item)
+			}
 			m.mode = modeList
 			m.itemToDelete = nil
-			m.setStatus("Item deleted")
+			m.finishPrompt(true)
+			m.clearSelection()
+			// Widen if the narrowed subtree was deleted
+			if m.narrowRoot != nil && !containsItem(m.orgFile.Items, m.narrowRoot) {
+				m.narrowRoot = nil
+			}
+			if isFile {
+				m.setStatus(fmt.Sprintf("%s moved to the trash", targets[0].Title))
+			} else if len(targets) > 1 {
+				m.setStatus(fmt.Sprintf("%d items deleted", len(targets)))
+			} else {
+				m.setStatus("Item deleted")
+			}
 			// Adjust cursor if needed
 			items := m.getVisibleItems()
 			if m.cursor >= len(items) && len(items) > 0 {
@@ -409,6 +580,7 @@ func (m uiModel) updateConfirmDelete(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "n", "N", "esc":
 			m.mode = modeList
 			m.itemToDelete = nil
+			m.finishPrompt(false)
 			m.setStatus("Cancelled")
 		}
 	}
@@ -440,11 +612,21 @@ func (m uiModel) updateCapture(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Notes:    []string{},
 					Children: []*model.Item{},
 				}
+				if m.config.IDs.AutoCreate {
+					newItem.EnsureID()
+				}
 
 				// Check if we're in multi-file mode
-				isMultiFile := len(m.orgFile.Items) > 0 && m.orgFile.Items[0].SourceFile != ""
-
-				if isMultiFile {
+				isMultiFile := m.orgFile.IsMultiFile()
+
+				if m.narrowRoot != nil {
+					// When narrowed, the subtree acts as the whole file
+					newItem.Level = m.narrowRoot.Level + 1
+					newItem.SourceFile = m.narrowRoot.SourceFile
+					m.narrowRoot.Children = append([]*model.Item{newItem}, m.narrowRoot.Children...)
+					m.narrowRoot.Folded = false
+					m.setStatus("TODO captured to " + m.narrowRoot.Title)
+				} else if isMultiFile {
 					// In multi-file mode, add to the file of the highlighted item (using stored cursor position)
 					items := m.getVisibleItems()
 					targetFileItem := m.findTopLevelFileItem(items, m.captureCursor)
@@ -452,7 +634,7 @@ func (m uiModel) updateCapture(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if targetFileItem != nil {
 						// Set the source file for the new item
 						newItem.SourceFile = targetFileItem.SourceFile
-						newItem.Level = 2 // Children of file items are level 2
+						newItem.Level = targetFileItem.Level + 1
 
 						// Insert at the beginning of the file item's children
 						targetFileItem.Children = append([]*model.Item{newItem}, targetFileItem.Children...)
@@ -483,35 +665,37 @@ func (m uiModel) updateCapture(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-// findTopLevelFileItem finds the top-level file item that contains the item at the given cursor position
+// findTopLevelFileItem finds the file item that contains the item at the given cursor position
 func (m *uiModel) findTopLevelFileItem(items []*model.Item, cursorPos int) *model.Item {
-	if cursorPos < 0 || cursorPos >= len(items) {
-		// Fallback to first file if cursor out of bounds
-		if len(m.orgFile.Items) > 0 {
-			return m.orgFile.Items[0]
-		}
-		return nil
-	}
-
-	selectedItem := items[cursorPos]
-
 	// Check if we're in multi-file mode
-	isMultiFile := len(m.orgFile.Items) > 0 && m.orgFile.Items[0].SourceFile != ""
-
-	if !isMultiFile {
+	if !m.orgFile.IsMultiFile() {
 		// Not in multi-file mode, return nil
 		return nil
 	}
 
-	// If the selected item itself is a file item (level 1 with SourceFile), return it
-	if selectedItem.SourceFile != "" && selectedItem.Level == 1 {
-		return selectedItem
+	fileItems := model.FileItems(m.orgFile.Items)
+	if len(fileItems) == 0 {
+		return nil
+	}
+	if cursorPos < 0 || cursorPos >= len(items) {
+		// Fallback to first file if cursor out of bounds
+		return fileItems[0]
 	}
 
-	// Otherwise, find which top-level file item this item belongs to
-	// by checking the SourceFile field
-	if selectedItem.SourceFile != "" {
-		for _, fileItem := range m.orgFile.Items {
+	selectedItem := items[cursorPos]
+	switch selectedItem.Wrapper {
+	case model.FileWrapper:
+		// The selected item itself is a file item
+		return selectedItem
+	case model.DirWrapper:
+		// Use the first file in the selected directory
+		if dirFiles := model.FileItems(selectedItem.Children); len(dirFiles) > 0 {
+			return dirFiles[0]
+		}
+	default:
+		// Otherwise, find which file item this item belongs to
+		// by checking the SourceFile field
+		for _, fileItem := range fileItems {
 			if fileItem.SourceFile == selectedItem.SourceFile {
 				return fileItem
 			}
@@ -519,11 +703,7 @@ func (m *uiModel) findTopLevelFileItem(items []*model.Item, cursorPos int) *mode
 	}
 
 	// Fallback: return the first file item
-	if len(m.orgFile.Items) > 0 {
-		return m.orgFile.Items[0]
-	}
-
-	return nil
+	return fileItems[0]
 }
 
 func (m uiModel) updateAddSubTask(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -552,6 +732,9 @@ func (m uiModel) updateAddSubTask(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Children:   []*model.Item{},
 					SourceFile: m.editingItem.SourceFile, // Inherit source file from parent
 				}
+				if m.config.IDs.AutoCreate {
+					newItem.EnsureID()
+				}
 				m.editingItem.Children = append(m.editingItem.Children, newItem)
 				m.editingItem.Folded = false // Unfold to show new sub-task
 				m.setStatus("Sub-task added!")
@@ -623,84 +806,48 @@ func (m uiModel) updateSetDate(msg tea.Msg, dateType string) (tea.Model, tea.Cmd
 		switch msg.Type {
 		case tea.KeyEnter:
 			input := strings.TrimSpace(m.textinput.Value())
-			if m.editingItem != nil {
-				var prefixDate string
+			applied := false
+			targets := m.promptTargets()
+			if len(targets) > 0 {
 				var clearedDateMsg string
 				var setDateMsg string
 
 				if dateType == "DEADLINE" {
-					prefixDate = "DEADLINE:"
 					clearedDateMsg = "Deadline cleared!"
 					setDateMsg = "Deadline set!"
 				} else {
-					prefixDate = "SCHEDULED:"
 					clearedDateMsg = "Scheduled date cleared!"
 					setDateMsg = "Scheduled date set!"
 				}
 
 				if input == "" {
 					// Empty input clears the date
-					if dateType == "DEADLINE" {
-						m.editingItem.Deadline = nil
-					} else {
-						m.editingItem.Scheduled = nil
+					for _, item := range targets {
+						setItemDate(item, dateType, nil)
 					}
-
-					// Remove property line from notes
-					var filteredNotes []string
-					for _, note := range m.editingItem.Notes {
-						trimmedNote := strings.TrimSpace(note)
-						if !strings.HasPrefix(trimmedNote, prefixDate) {
-							filteredNotes = append(filteredNotes, note)
-						}
-					}
-					m.editingItem.Notes = filteredNotes
-					m.setStatus(clearedDateMsg)
+					applied = true
+					m.reportBulkPrompt(len(targets), clearedDateMsg)
 				} else {
 					dateVal, err := parseDateInput(input)
 					if err != nil {
 						m.setStatus(fmt.Sprintf("Invalid date: %v", err))
 					} else {
-						if dateType == "DEADLINE" {
-							m.editingItem.Deadline = &dateVal
-						} else {
-							m.editingItem.Scheduled = &dateVal
+						for _, item := range targets {
+							setItemDate(item, dateType, &dateVal)
 						}
-
-						// Also update or add property line in notes
-						updatedNotes := false
-						for i, note := range m.editingItem.Notes {
-							trimmedNote := strings.TrimSpace(note)
-							if strings.HasPrefix(trimmedNote, prefixDate) {
-								m.editingItem.Notes[i] = fmt.Sprintf("%s <%s>", prefixDate, parser.FormatOrgDate(dateVal))
-								updatedNotes = true
-								break
-							}
-						}
-						// If property wasn't in notes, it will be added by writeItem
-						if !updatedNotes {
-							// Remove old property lines just to be safe
-							var filteredNotes []string
-							for _, note := range m.editingItem.Notes {
-								trimmedNote := strings.TrimSpace(note)
-								if !strings.HasPrefix(trimmedNote, prefixDate) {
-									filteredNotes = append(filteredNotes, note)
-								}
-							}
-							m.editingItem.Notes = filteredNotes
-						}
-						m.setStatus(setDateMsg)
+						applied = true
+						m.reportBulkPrompt(len(targets), setDateMsg)
 					}
 				}
 			}
 			m.mode = modeList
 			m.textinput.Blur()
-			m.editingItem = nil
+			m.finishPrompt(applied)
 			return m, nil
 		case tea.KeyEsc:
 			m.mode = modeList
 			m.textinput.Blur()
-			m.editingItem = nil
+			m.finishPrompt(false)
 			m.setStatus("Cancelled")
 			return m, nil
 		}
@@ -710,6 +857,20 @@ func (m uiModel) updateSetDate(msg tea.Msg, dateType string) (tea.Model, tea.Cmd
 	return m, cmd
 }
 
+// setItemDate sets or clears (when dateVal is nil) an item's DEADLINE or SCHEDULED date
+func setItemDate(item *model.Item, dateType string, dateVal *time.Time) {
+	var date *time.Time
+	if dateVal != nil {
+		d := *dateVal
+		date = &d
+	}
+	if dateType == "DEADLINE" {
+		item.Deadline = date
+	} else {
+		item.Scheduled = date
+	}
+}
+
 func (m uiModel) updateSetPriority(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -717,46 +878,42 @@ func (m uiModel) updateSetPriority(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 	case tea.KeyMsg:
+		var priority model.Priority
+		var statusMsg string
+
 		switch msg.String() {
 		case "A", "a":
-			if m.editingItem != nil {
-				m.editingItem.Priority = model.PriorityA
-				m.setStatus("Priority set to A")
-			}
-			m.mode = modeList
-			m.editingItem = nil
-			return m, nil
+			priority = model.PriorityA
+			statusMsg = "Priority set to A"
 		case "B", "b":
-			if m.editingItem != nil {
-				m.editingItem.Priority = model.PriorityB
-				m.setStatus("Priority set to B")
-			}
-			m.mode = modeList
-			m.editingItem = nil
-			return m, nil
+			priority = model.PriorityB
+			statusMsg = "Priority set to B"
 		case "C", "c":
-			if m.editingItem != nil {
-				m.editingItem.Priority = model.PriorityC
-				m.setStatus("Priority set to C")
-			}
-			m.mode = modeList
-			m.editingItem = nil
-			return m, nil
+			priority = model.PriorityC
+			statusMsg = "Priority set to C"
 		case " ", "enter":
 			// Clear priority
-			if m.editingItem != nil {
-				m.editingItem.Priority = model.PriorityNone
-				m.setStatus("Priority cleared")
-			}
-			m.mode = modeList
-			m.editingItem = nil
-			return m, nil
+			priority = model.PriorityNone
+			statusMsg = "Priority cleared"
 		case "esc":
 			m.mode = modeList
-			m.editingItem = nil
+			m.finishPrompt(false)
 			m.setStatus("Cancelled")
 			return m, nil
+		default:
+			return m, nil
+		}
+
+		targets := m.promptTargets()
+		for _, item := range targets {
+			item.Priority = priority
 		}
+		if len(targets) > 0 {
+			m.reportBulkPrompt(len(targets), statusMsg)
+		}
+		m.mode = modeList
+		m.finishPrompt(true)
+		return m, nil
 	}
 	return m, nil
 }
@@ -777,12 +934,14 @@ func (m uiModel) updateSetEffort(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.editingItem != nil {
 				if input == "" {
 					// Empty input clears the effort
-					m.editingItem.Effort = ""
+					m.editingItem.SetEffort("")
 					m.setStatus("Effort cleared!")
-				} else {
+				} else if effort, ok := model.ParseEffort(input, m.effortUnits()); ok {
 					// Set the effort value
-					m.editingItem.Effort = input
-					m.setStatus("Effort set!")
+					m.editingItem.SetEffort(input)
+					m.setStatus(fmt.Sprintf("Effort set! (%s)", formatDuration(effort)))
+				} else {
+					m.setStatus(fmt.Sprintf("Invalid effort: %s", input))
 				}
 			}
 			m.mode = modeList
@@ -802,10 +961,11 @@ func (m uiModel) updateSetEffort(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
-func (m *uiModel) cycleStateForward(item *model.Item) {
+// cycleStateForward moves the item to the next state, returning false if it is blocked from completion
+func (m *uiModel) cycleStateForward(item *model.Item) bool {
 	stateNames := m.config.GetStateNames()
 	if len(stateNames) == 0 {
-		return
+		return false
 	}
 
 	// Find current state index
@@ -840,6 +1000,12 @@ func (m *uiModel) cycleStateForward(item *model.Item) {
 		newState = stateNames[currentIndex+1]
 	}
 
+	// Refuse to complete an item while its blockers are open
+	if msg := m.blockedMessage(item, model.TodoState(newState)); msg != "" {
+		m.setStatus(msg)
+		return false
+	}
+
 	// Update the item state
 	item.State = model.TodoState(newState)
 
@@ -851,32 +1017,18 @@ func (m *uiModel) cycleStateForward(item *model.Item) {
 		// Moving TO done state - add CLOSED timestamp
 		now := time.Now()
 		item.Closed = &now
-		// Remove any existing CLOSED line from notes
-		var filteredNotes []string
-		for _, note := range item.Notes {
-			if !strings.HasPrefix(strings.TrimSpace(note), "CLOSED:") {
-				filteredNotes = append(filteredNotes, note)
-			}
-		}
-		item.Notes = filteredNotes
 	} else if wasInDoneState && !isInDoneState {
 		// Moving FROM done state - remove CLOSED timestamp
 		item.Closed = nil
-		// Remove any existing CLOSED line from notes
-		var filteredNotes []string
-		for _, note := range item.Notes {
-			if !strings.HasPrefix(strings.TrimSpace(note), "CLOSED:") {
-				filteredNotes = append(filteredNotes, note)
-			}
-		}
-		item.Notes = filteredNotes
 	}
+	return true
 }
 
-func (m *uiModel) cycleStateBackward(item *model.Item) {
+// cycleStateBackward moves the item to the previous state, returning false if it is blocked from completion
+func (m *uiModel) cycleStateBackward(item *model.Item) bool {
 	stateNames := m.config.GetStateNames()
 	if len(stateNames) == 0 {
-		return
+		return false
 	}
 
 	// Find current state index
@@ -909,6 +1061,12 @@ func (m *uiModel) cycleStateBackward(item *model.Item) {
 		newState = stateNames[currentIndex-1]
 	}
 
+	// Refuse to complete an item while its blockers are open
+	if msg := m.blockedMessage(item, model.TodoState(newState)); msg != "" {
+		m.setStatus(msg)
+		return false
+	}
+
 	// Update the item state
 	item.State = model.TodoState(newState)
 
@@ -920,26 +1078,11 @@ func (m *uiModel) cycleStateBackward(item *model.Item) {
 		// Moving TO done state - add CLOSED timestamp
 		now := time.Now()
 		item.Closed = &now
-		// Remove any existing CLOSED line from notes
-		var filteredNotes []string
-		for _, note := range item.Notes {
-			if !strings.HasPrefix(strings.TrimSpace(note), "CLOSED:") {
-				filteredNotes = append(filteredNotes, note)
-			}
-		}
-		item.Notes = filteredNotes
 	} else if wasInDoneState && !isInDoneState {
 		// Moving FROM done state - remove CLOSED timestamp
 		item.Closed = nil
-		// Remove any existing CLOSED line from notes
-		var filteredNotes []string
-		for _, note := range item.Notes {
-			if !strings.HasPrefix(strings.TrimSpace(note), "CLOSED:") {
-				filteredNotes = append(filteredNotes, note)
-			}
-		}
-		item.Notes = filteredNotes
 	}
+	return true
 }
 
 func (m *uiModel) deleteItem(item *model.Item) {
@@ -956,6 +1099,23 @@ func (m *uiModel) deleteItem(item *model.Item) {
 		return result
 	}
 	m.orgFile.Items = removeFromList(m.orgFile.Items, item)
+	m.orgFile.BuildIDIndex()
+	if m.agendaFiles != nil {
+		// Items deleted in the agenda view may come from the other agenda files
+		m.agendaFiles.Items = removeFromList(m.agendaFiles.Items, item)
+		m.agendaFiles.BuildIDIndex()
+	}
+}
+
+// save writes the edited file and the other agenda files back to disk
+func (m uiModel) save() error {
+	if err := parser.Save(m.orgFile); err != nil {
+		return err
+	}
+	if m.agendaFiles != nil {
+		return parser.Save(m.agendaFiles)
+	}
+	return nil
 }
 
 func (m *uiModel) moveItemUp() {
@@ -1085,6 +1245,12 @@ func (m *uiModel) promoteItem() {
 
 	currentItem := items[m.cursor]
 
+	// Files and directories keep their place in the directory tree
+	if currentItem.IsWrapper() {
+		m.setStatus("Cannot promote file or directory items")
+		return
+	}
+
 	// Can't promote a top-level item
 	if currentItem.Level <= 1 {
 		m.setStatus("Cannot promote - already at top level")
@@ -1098,6 +1264,12 @@ func (m *uiModel) promoteItem() {
 		return
 	}
 
+	// Items can't leave the file they belong to
+	if parent.IsWrapper() {
+		m.setStatus("Cannot promote - already at the top level of its file")
+		return
+	}
+
 	// Remove item from parent's children
 	for i, child := range parent.Children {
 		if child == currentItem {
@@ -1150,6 +1322,12 @@ func (m *uiModel) demoteItem() {
 
 	currentItem := items[m.cursor]
 
+	// Files and directories keep their place in the directory tree
+	if currentItem.IsWrapper() {
+		m.setStatus("Cannot demote file or directory items")
+		return
+	}
+
 	// Find the previous sibling to make this item its child
 	prevSibling := m.findPreviousSibling(currentItem)
 	if prevSibling == nil {
@@ -1265,33 +1443,24 @@ func (m *uiModel) updateTagEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
-		case key.Matches(msg, m.keys.Quit):
+		case key.Matches(msg, m.keys.Quit), msg.Type == tea.KeyEsc:
 			m.mode = modeList
 			m.textinput.Blur()
+			m.finishPrompt(false)
 			return m, nil
 
 		case msg.Type == tea.KeyEnter:
-			if m.editingItem != nil {
-				// Parse tags from input (colon-separated)
-				tagsStr := m.textinput.Value()
-				var tags []string
-				if tagsStr != "" {
-					tags = strings.Split(tagsStr, ":")
-					// Remove empty strings
-					var filteredTags []string
-					for _, tag := range tags {
-						tag = strings.TrimSpace(tag)
-						if tag != "" {
-							filteredTags = append(filteredTags, tag)
-						}
-					}
-					tags = filteredTags
-				}
-				m.editingItem.Tags = tags
-				m.setStatus("Tags updated")
+			targets := m.promptTargets()
+			for _, item := range targets {
+				// Parse tags from input (colon-separated, optionally +tag/-tag)
+				applyTagInput(item, m.textinput.Value())
+			}
+			if len(targets) > 0 {
+				m.reportBulkPrompt(len(targets), "Tags updated")
 			}
 			m.mode = modeList
 			m.textinput.Blur()
+			m.finishPrompt(true)
 			return m, nil
 
 		default:
@@ -1315,7 +1484,10 @@ func (m *uiModel) updateRename(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case msg.Type == tea.KeyEnter:
-			if m.editingItem != nil {
+			if m.editingItem != nil && m.editingItem.Wrapper == model.FileWrapper {
+				// Renaming a file item renames the file on disk
+				m.renameFile(m.editingItem, m.textinput.Value())
+			} else if m.editingItem != nil {
 				newTitle := strings.TrimSpace(m.textinput.Value())
 				if newTitle != "" {
 					m.editingItem.Title = newTitle
@@ -1344,3 +1516,47 @@ func (m *uiModel) updateRename(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 	return m, nil
 }
+
+// updateFilter handles the filter prompt
+func (m uiModel) updateFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.textinput.Width = 50
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			query := strings.TrimSpace(m.textinput.Value())
+			if query == "" {
+				m.filter = nil
+				m.setStatus("Filter cleared")
+			} else {
+				filter, err := parseFilter(query)
+				if err != nil {
+					m.setStatus(fmt.Sprintf("Invalid filter: %v", err))
+					return m, nil
+				}
+				m.filter = filter
+				m.setStatus("Filter applied")
+			}
+			m.cursor = 0
+			m.scrollOffset = 0
+			m.clearSelection()
+			m.mode = modeList
+			m.textinput.Blur()
+			return m, nil
+		case tea.KeyEsc:
+			m.mode = modeList
+			m.textinput.Blur()
+			m.setStatus("Cancelled")
+			return m, nil
+		}
+	}
+
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
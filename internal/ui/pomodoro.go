@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pomodoroEndMsg is sent when a pomodoro or its break ends
+type pomodoroEndMsg struct {
+	end time.Time
+}
+
+// pomodoroNotifiedMsg reports the result of the notification command
+type pomodoroNotifiedMsg struct {
+	err error
+}
+
+// pomodoroTimer waits until end
+func pomodoroTimer(end time.Time) tea.Cmd {
+	return tea.Tick(time.Until(end), func(time.Time) tea.Msg {
+		return pomodoroEndMsg{end: end}
+	})
+}
+
+// togglePomodoro starts a pomodoro on the item under the cursor, clocking in to
+// it, or stops the one running
+func (m *uiModel) togglePomodoro() tea.Cmd {
+	if m.pomodoroItem != nil {
+		item := m.pomodoroItem
+		if !m.pomodoroBreak {
+			item.ClockOut()
+		}
+		m.pomodoroItem = nil
+		m.pomodoroEnd = time.Time{}
+		m.setStatus(fmt.Sprintf("Pomodoro on %s stopped", item.Title))
+		return nil
+	}
+
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return nil
+	}
+	item := items[m.cursor]
+	work := time.Duration(m.config.Pomodoro.WorkMinutes) * time.Minute
+	m.pomodoroItem = item
+	m.pomodoroBreak = false
+	m.pomodoroEnd = time.Now().Add(work)
+
+	if previous := m.clockIn(item); previous != nil {
+		m.setStatus(fmt.Sprintf("Clocked out of %s, pomodoro of %s started", previous.Title, formatDuration(work)))
+	} else {
+		m.setStatus(fmt.Sprintf("Pomodoro of %s started", formatDuration(work)))
+	}
+	return pomodoroTimer(m.pomodoroEnd)
+}
+
+// endPomodoro finishes a pomodoro, logging it and clocking out at its end, and
+// starts the break, or finishes the break
+func (m *uiModel) endPomodoro(msg pomodoroEndMsg) tea.Cmd {
+	// Timers of pomodoros stopped early are left to run out
+	if m.pomodoroItem == nil || !msg.end.Equal(m.pomodoroEnd) {
+		return nil
+	}
+
+	item := m.pomodoroItem
+	if m.pomodoroBreak {
+		m.pomodoroItem = nil
+		m.pomodoroEnd = time.Time{}
+		m.setStatus("Break over")
+		return tea.Batch(ringBell(), m.notify("Break over"))
+	}
+
+	// A pomodoro whose item was clocked out of before the end was interrupted
+	if !item.ClockOutAt(msg.end) {
+		m.pomodoroItem = nil
+		m.pomodoroEnd = time.Time{}
+		m.setStatus(fmt.Sprintf("Pomodoro on %s not completed, it was clocked out of", item.Title))
+		return ringBell()
+	}
+	item.AddLogbookNote(fmt.Sprintf("- Pomodoro completed on [%s]", msg.end.Format("2006-01-02 Mon 15:04")))
+
+	rest := time.Duration(m.config.Pomodoro.BreakMinutes) * time.Minute
+	m.pomodoroBreak = true
+	m.pomodoroEnd = time.Now().Add(rest)
+	status := fmt.Sprintf("Pomodoro on %s completed, take a %s break", item.Title, formatDuration(rest))
+	m.setStatus(status)
+	return tea.Batch(ringBell(), m.notify(status), pomodoroTimer(m.pomodoroEnd))
+}
+
+// ringBell rings the terminal bell
+func ringBell() tea.Cmd {
+	return func() tea.Msg {
+		fmt.Fprint(os.Stdout, "\a")
+		return nil
+	}
+}
+
+// notify runs the configured notification command with a message as its last
+// argument, or does nothing if there is none
+func (m uiModel) notify(message string) tea.Cmd {
+	command := strings.Fields(m.config.Pomodoro.NotifyCommand)
+	if len(command) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		err := exec.Command(command[0], append(command[1:], message)...).Run()
+		return pomodoroNotifiedMsg{err: err}
+	}
+}
+
+// renderPomodoro renders the time left in the pomodoro or break for the status
+// line, or "" if none is running
+func (m uiModel) renderPomodoro() string {
+	if m.pomodoroItem == nil {
+		return ""
+	}
+	left := time.Until(m.pomodoroEnd).Round(time.Minute)
+	if left < time.Minute {
+		left = time.Minute
+	}
+	if m.pomodoroBreak {
+		return fmt.Sprintf("[BREAK: %s left]", formatDuration(left))
+	}
+	return fmt.Sprintf("[POMODORO: %s left]", formatDuration(left))
+}
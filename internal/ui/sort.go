@@ -0,0 +1,312 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+	"github.com/rwejlgaard/org/internal/parser"
+)
+
+// sortKey is a single sort criterion, identified by its org-sort style key letter
+type sortKey struct {
+	criterion  rune // a, o, p, d, s, c, k or e
+	descending bool
+}
+
+// sortCriteria lists the supported sort criteria in display order
+var sortCriteria = []struct {
+	key  rune
+	name string
+}{
+	{'a', "Alphabetical (title)"},
+	{'o', "State order"},
+	{'p', "Priority"},
+	{'d', "Deadline"},
+	{'s', "Scheduled date"},
+	{'c', "Creation time (:CREATED: property)"},
+	{'k', "Clocked time"},
+	{'e', "Effort"},
+}
+
+// sortCriterionName returns the display name for a criterion key
+func sortCriterionName(criterion rune) string {
+	for _, c := range sortCriteria {
+		if c.key == criterion {
+			return c.name
+		}
+	}
+	return string(criterion)
+}
+
+// describeSortKey formats a sort key for display, e.g. "Priority (descending)"
+func describeSortKey(k sortKey) string {
+	direction := "ascending"
+	if k.descending {
+		direction = "descending"
+	}
+	return fmt.Sprintf("%s (%s)", sortCriterionName(k.criterion), direction)
+}
+
+// parseSortKey converts a key press into a sort key; uppercase sorts descending
+func parseSortKey(s string) (sortKey, bool) {
+	runes := []rune(s)
+	if len(runes) != 1 {
+		return sortKey{}, false
+	}
+	lower := []rune(strings.ToLower(s))[0]
+	for _, c := range sortCriteria {
+		if c.key == lower {
+			return sortKey{criterion: lower, descending: runes[0] != lower}, true
+		}
+	}
+	return sortKey{}, false
+}
+
+// sortValue extracts a comparable value for an item; ok is false if the item has no value
+func (m *uiModel) sortValue(item *model.Item, criterion rune) (value float64, text string, ok bool) {
+	switch criterion {
+	case 'a':
+		return 0, strings.ToLower(item.Title), true
+	case 'o':
+		if item.State == model.StateNone {
+			return 0, "", false
+		}
+		for i, name := range m.config.GetStateNames() {
+			if name == string(item.State) {
+				return float64(i), "", true
+			}
+		}
+		return 0, "", false
+	case 'p':
+		switch item.Priority {
+		case model.PriorityA:
+			return 0, "", true
+		case model.PriorityB:
+			return 1, "", true
+		case model.PriorityC:
+			return 2, "", true
+		}
+		return 0, "", false
+	case 'd':
+		return timeSortValue(item.Deadline)
+	case 's':
+		return timeSortValue(item.Scheduled)
+	case 'c':
+		created := item.GetProperty("CREATED")
+		if created == "" {
+			return 0, "", false
+		}
+		t, err := parser.ParseTimestamp(created)
+		if err != nil {
+			return 0, "", false
+		}
+		return timeSortValue(&t)
+	case 'k':
+		if len(item.ClockEntries) == 0 {
+			return 0, "", false
+		}
+		return float64(item.GetTotalClockDuration()), "", true
+	case 'e':
+		effort, ok := model.ParseEffort(item.GetEffort(), m.effortUnits())
+		if !ok {
+			return 0, "", false
+		}
+		return float64(effort), "", true
+	}
+	return 0, "", false
+}
+
+// timeSortValue converts an optional time into a sort value
+func timeSortValue(t *time.Time) (float64, string, bool) {
+	if t == nil {
+		return 0, "", false
+	}
+	return float64(t.Unix()), "", true
+}
+
+// compareItems compares two items by a single sort key.
+// Items without a value always sort after items with one, regardless of direction.
+func (m *uiModel) compareItems(a, b *model.Item, k sortKey) int {
+	av, at, aok := m.sortValue(a, k.criterion)
+	bv, bt, bok := m.sortValue(b, k.criterion)
+
+	switch {
+	case !aok && !bok:
+		return 0
+	case !aok:
+		return 1
+	case !bok:
+		return -1
+	}
+
+	result := 0
+	switch {
+	case at < bt, at == bt && av < bv:
+		result = -1
+	case at > bt, at == bt && av > bv:
+		result = 1
+	}
+	if k.descending {
+		result = -result
+	}
+	return result
+}
+
+// sortItemList stably sorts a list of sibling items in place by the given keys
+func (m *uiModel) sortItemList(items []*model.Item, keys []sortKey) {
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, k := range keys {
+			if c := m.compareItems(items[i], items[j], k); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// startSort opens the sort dialog for the item under the cursor
+func (m *uiModel) startSort() {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return
+	}
+	m.editingItem = items[m.cursor]
+	m.sortKeys = nil
+	// Sort the children of the current item, or its siblings if it has none
+	m.sortTopLevel = m.findParent(m.editingItem) == nil && len(m.editingItem.Children) == 0
+	m.mode = modeSort
+}
+
+// sortScope returns the list of items the sort dialog will reorder and a description of it
+func (m *uiModel) sortScope() ([]*model.Item, string) {
+	if m.sortTopLevel || m.editingItem == nil {
+		return m.topLevelSortScope()
+	}
+	if len(m.editingItem.Children) > 0 {
+		return m.editingItem.Children, fmt.Sprintf("children of \"%s\"", m.editingItem.Title)
+	}
+	// The siblings of the narrowed item are outside the view
+	if parent := m.findParent(m.editingItem); parent != nil && m.editingItem != m.narrowRoot {
+		return parent.Children, fmt.Sprintf("children of \"%s\"", parent.Title)
+	}
+	return m.topLevelSortScope()
+}
+
+// topLevelSortScope returns the items at the top of the view: the children of
+// the item the view is narrowed to, or the top-level items
+func (m *uiModel) topLevelSortScope() ([]*model.Item, string) {
+	if m.narrowRoot != nil {
+		return m.narrowRoot.Children, fmt.Sprintf("children of \"%s\"", m.narrowRoot.Title)
+	}
+	return m.orgFile.Items, "top-level items"
+}
+
+// applySort sorts the current scope by the collected keys and keeps the cursor on its item
+func (m *uiModel) applySort() {
+	current := m.editingItem
+	list, scope := m.sortScope()
+	m.sortItemList(list, m.sortKeys)
+
+	var descriptions []string
+	for _, k := range m.sortKeys {
+		descriptions = append(descriptions, describeSortKey(k))
+	}
+	m.setStatus(fmt.Sprintf("Sorted %s by %s", scope, strings.Join(descriptions, ", then ")))
+
+	for i, item := range m.getVisibleItems() {
+		if item == current {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// updateSort handles key presses in the sort dialog
+func (m uiModel) updateSort(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.mode = modeList
+			m.editingItem = nil
+			m.sortKeys = nil
+			m.setStatus("Cancelled")
+			return m, nil
+		case "tab":
+			m.sortTopLevel = !m.sortTopLevel
+			return m, nil
+		case "enter":
+			// Enter skips the secondary key once a primary key is chosen
+			if len(m.sortKeys) > 0 {
+				m.applySort()
+				m.mode = modeList
+				m.editingItem = nil
+				m.sortKeys = nil
+			}
+			return m, nil
+		}
+
+		if k, ok := parseSortKey(msg.String()); ok {
+			m.sortKeys = append(m.sortKeys, k)
+			if len(m.sortKeys) >= 2 {
+				m.applySort()
+				m.mode = modeList
+				m.editingItem = nil
+				m.sortKeys = nil
+			}
+		}
+	}
+	return m, nil
+}
+
+// viewSort renders the sort dialog
+func (m uiModel) viewSort() string {
+	dialogStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(60)
+
+	var content strings.Builder
+	if len(m.sortKeys) == 0 {
+		content.WriteString(m.styles.titleStyle.Render("Sort Items"))
+	} else {
+		content.WriteString(m.styles.titleStyle.Render("Sort Items - Secondary Key"))
+	}
+	content.WriteString("\n")
+	_, scope := m.sortScope()
+	content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Sorting: %s", scope)))
+	content.WriteString("\n")
+	for i, k := range m.sortKeys {
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Key %d: %s", i+1, describeSortKey(k))))
+		content.WriteString("\n")
+	}
+	content.WriteString("\n")
+
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("99")).Bold(true)
+	for _, c := range sortCriteria {
+		content.WriteString(fmt.Sprintf("%s  %s\n", keyStyle.Render(fmt.Sprintf("[%c]", c.key)), c.name))
+	}
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("Lowercase sorts ascending, uppercase descending"))
+	content.WriteString("\n")
+	if len(m.sortKeys) > 0 {
+		content.WriteString(m.styles.statusStyle.Render("Press Enter to sort without a secondary key"))
+		content.WriteString("\n")
+	}
+	content.WriteString(m.styles.statusStyle.Render("Tab: toggle top level • ESC to cancel"))
+
+	dialog := dialogStyle.Render(content.String())
+
+	// Center the dialog horizontally and vertically
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
@@ -18,6 +18,9 @@ type styleMap struct {
 	statusStyle    lipgloss.Style
 	noteStyle      lipgloss.Style
 	foldedStyle    lipgloss.Style
+	markedStyle    lipgloss.Style
+	linkStyle      lipgloss.Style
+	codeStyle      lipgloss.Style
 }
 
 // newStyleMapFromConfig creates a styleMap from configuration
@@ -36,5 +39,8 @@ func newStyleMapFromConfig(cfg *config.Config) styleMap {
 		statusStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Status)).Italic(true),
 		noteStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Note)).Italic(true),
 		foldedStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Folded)),
+		markedStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Marked)).Bold(true),
+		linkStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Link)).Underline(true),
+		codeStyle:      lipgloss.NewStyle().Foreground(lipgloss.Color(colors.Code)),
 	}
 }
@@ -0,0 +1,391 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwejlgaard/org/internal/model"
+)
+
+// tableCell is the position of the cell being edited, or {-1, -1} for none
+type tableCell struct {
+	row, col int
+}
+
+var noTableCell = tableCell{-1, -1}
+
+// renderTable draws an org table with box-drawing borders. Rows above the first
+// horizontal rule are shown as headers, and the given cell is highlighted with
+// the text being typed into it.
+func (m uiModel) renderTable(table *model.Table, current tableCell, currentValue string) []string {
+	borderStyle := m.styles.foldedStyle
+	headerStyle := lipgloss.NewStyle().Bold(true)
+
+	// Rules before the first and after the last data row become the outer borders
+	first, last := -1, -1
+	for i, row := range table.Rows {
+		if !row.Rule {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	headerEnd := -1
+	for i := first + 1; i < last; i++ {
+		if table.Rows[i].Rule {
+			headerEnd = i
+			break
+		}
+	}
+
+	// Render the cells first so widths account for hidden markup and links
+	width := table.Width()
+	cells := make([][]string, len(table.Rows))
+	widths := make([]int, width)
+	for i, row := range table.Rows {
+		if row.Rule {
+			continue
+		}
+		cells[i] = make([]string, width)
+		for col := 0; col < width; col++ {
+			text := row.Cells[col]
+			if (tableCell{i, col}) == current {
+				text = currentValue
+			}
+			cells[i][col] = m.renderInline(protectLinks(text))
+			if w := lipgloss.Width(cells[i][col]); w > widths[col] {
+				widths[col] = w
+			}
+		}
+	}
+
+	border := func(left, middle, right string) string {
+		var parts []string
+		for _, w := range widths {
+			parts = append(parts, strings.Repeat("─", w+2))
+		}
+		return table.Indent + borderStyle.Render(left+strings.Join(parts, middle)+right)
+	}
+
+	lines := []string{border("┌", "┬", "┐")}
+	for i, row := range table.Rows {
+		if i < first || i > last {
+			continue
+		}
+		if row.Rule {
+			lines = append(lines, border("├", "┼", "┤"))
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString(table.Indent)
+		for col := 0; col < width; col++ {
+			cell := cells[i][col]
+			padding := strings.Repeat(" ", widths[col]-lipgloss.Width(cell))
+			if table.IsNumericColumn(col) {
+				cell = padding + cell
+			} else {
+				cell = cell + padding
+			}
+			switch {
+			case (tableCell{i, col}) == current:
+				cell = m.styles.cursorStyle.Render(cell)
+			case i < headerEnd:
+				cell = headerStyle.Render(cell)
+			}
+			b.WriteString(borderStyle.Render("│ ") + cell + " ")
+		}
+		b.WriteString(borderStyle.Render("│"))
+		lines = append(lines, b.String())
+	}
+	lines = append(lines, border("└", "┴", "┘"))
+
+	if len(table.Formulas) > 0 {
+		lines = append(lines, table.Indent+borderStyle.Render("#+TBLFM: "+strings.Join(table.Formulas, "::")))
+	}
+	return lines
+}
+
+// startTableEdit opens the current item's first table for editing, or a new
+// table at the end of its notes if it has none
+func (m *uiModel) startTableEdit() tea.Cmd {
+	items := m.getVisibleItems()
+	if len(items) == 0 || m.cursor >= len(items) {
+		return nil
+	}
+	item := items[m.cursor]
+
+	if item.IsWrapper() {
+		m.setStatus("Cannot add notes to file or directory items")
+		return nil
+	}
+
+	m.editingItem = item
+	m.tableIndex = 0
+	m.openTable()
+	m.mode = modeTableEdit
+	m.textinput.Placeholder = ""
+	m.textinput.Focus()
+	return textinput.Blink
+}
+
+// openTable loads the table at tableIndex from the item's notes, creating one if
+// the item has no tables, and moves to its first data cell
+func (m *uiModel) openTable() {
+	tables := model.FindTables(m.editingItem.Notes)
+	if len(tables) == 0 {
+		m.table = model.NewTable(2)
+		m.table.Start = len(m.editingItem.Notes)
+		m.table.End = m.table.Start
+		m.tableIsNew = true
+	} else {
+		m.table = tables[m.tableIndex%len(tables)]
+		m.tableIsNew = false
+	}
+	row := m.nextDataRow(-1, 1)
+	if row < 0 {
+		// A table of only rules gets a row to type into
+		row = len(m.table.Rows)
+		m.table.InsertRow(row)
+	}
+	m.tableCell = tableCell{row: row, col: 0}
+	m.loadTableCell()
+}
+
+// tableVert is org's entity for a | in a table cell, where | would start a new cell
+const tableVert = `\vert`
+
+// loadTableCell puts the current cell's text into the input
+func (m *uiModel) loadTableCell() {
+	m.textinput.SetValue(strings.ReplaceAll(m.table.Rows[m.tableCell.row].Cells[m.tableCell.col], tableVert, "|"))
+	m.textinput.CursorEnd()
+}
+
+// storeTableCell writes the input back into the current cell
+func (m *uiModel) storeTableCell() {
+	value := strings.TrimSpace(m.textinput.Value())
+	m.table.Rows[m.tableCell.row].Cells[m.tableCell.col] = strings.ReplaceAll(value, "|", tableVert)
+}
+
+// nextDataRow returns the next row from the given one in the direction that is
+// not a horizontal rule, or -1 if there is none
+func (m uiModel) nextDataRow(from, direction int) int {
+	for row := from + direction; row >= 0 && row < len(m.table.Rows); row += direction {
+		if !m.table.Rows[row].Rule {
+			return row
+		}
+	}
+	return -1
+}
+
+// dataRowCount returns the number of rows that are not horizontal rules
+func (m uiModel) dataRowCount() int {
+	count := 0
+	for _, row := range m.table.Rows {
+		if !row.Rule {
+			count++
+		}
+	}
+	return count
+}
+
+// appendTableRow adds an empty row after the current one and moves to it
+func (m *uiModel) appendTableRow() {
+	m.table.InsertRow(m.tableCell.row + 1)
+	m.tableCell.row++
+}
+
+// saveTable recalculates and realigns the table and writes it into the item's notes
+func (m *uiModel) saveTable() {
+	m.storeTableCell()
+	if m.tableIsNew && tableIsEmpty(m.table) {
+		return
+	}
+	err := m.table.Recalculate()
+	m.editingItem.Notes = m.table.ReplaceIn(m.editingItem.Notes)
+	m.tableIsNew = false
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Table saved, formula error: %v", err))
+	} else {
+		m.setStatus("Table saved")
+	}
+}
+
+// tableIsEmpty returns true if no cell in the table has text
+func tableIsEmpty(table *model.Table) bool {
+	for _, row := range table.Rows {
+		for _, cell := range row.Cells {
+			if cell != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// updateTableEdit handles key presses while editing a table
+func (m uiModel) updateTableEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		width := m.table.Width()
+		switch msg.String() {
+		case "esc":
+			m.saveTable()
+			m.mode = modeList
+			m.table = nil
+			m.editingItem = nil
+			m.textinput.Blur()
+			return m, nil
+
+		case "tab":
+			// Move to the next cell, adding a row after the last one like org does
+			m.storeTableCell()
+			if m.tableCell.col < width-1 {
+				m.tableCell.col++
+			} else if next := m.nextDataRow(m.tableCell.row, 1); next >= 0 {
+				m.tableCell = tableCell{row: next, col: 0}
+			} else {
+				m.appendTableRow()
+				m.tableCell.col = 0
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "shift+tab":
+			m.storeTableCell()
+			if m.tableCell.col > 0 {
+				m.tableCell.col--
+			} else if prev := m.nextDataRow(m.tableCell.row, -1); prev >= 0 {
+				m.tableCell = tableCell{row: prev, col: width - 1}
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "enter", "down":
+			m.storeTableCell()
+			if next := m.nextDataRow(m.tableCell.row, 1); next >= 0 {
+				m.tableCell.row = next
+			} else if msg.String() == "enter" {
+				m.appendTableRow()
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "up":
+			m.storeTableCell()
+			if prev := m.nextDataRow(m.tableCell.row, -1); prev >= 0 {
+				m.tableCell.row = prev
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "ctrl+o":
+			m.storeTableCell()
+			m.appendTableRow()
+			m.loadTableCell()
+			return m, nil
+
+		case "ctrl+k":
+			if m.dataRowCount() <= 1 {
+				m.setStatus("Cannot delete the only row")
+				return m, nil
+			}
+			row := m.tableCell.row
+			m.table.DeleteRow(row)
+			if next := m.nextDataRow(row-1, 1); next >= 0 {
+				m.tableCell.row = next
+			} else {
+				m.tableCell.row = m.nextDataRow(row, -1)
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "ctrl+r":
+			// Insert a horizontal rule below the current row
+			m.storeTableCell()
+			m.table.InsertRule(m.tableCell.row + 1)
+			return m, nil
+
+		case "ctrl+l":
+			m.storeTableCell()
+			m.table.InsertColumn(m.tableCell.col + 1)
+			m.tableCell.col++
+			m.loadTableCell()
+			return m, nil
+
+		case "ctrl+x":
+			if width <= 1 {
+				m.setStatus("Cannot delete the only column")
+				return m, nil
+			}
+			m.table.DeleteColumn(m.tableCell.col)
+			if m.tableCell.col >= width-1 {
+				m.tableCell.col = width - 2
+			}
+			m.loadTableCell()
+			return m, nil
+
+		case "pgdown", "pgup":
+			// Switch to another table in the same notes
+			tables := model.FindTables(m.editingItem.Notes)
+			if len(tables) < 2 {
+				return m, nil
+			}
+			m.saveTable()
+			if msg.String() == "pgdown" {
+				m.tableIndex = (m.tableIndex + 1) % len(tables)
+			} else {
+				m.tableIndex = (m.tableIndex + len(tables) - 1) % len(tables)
+			}
+			m.openTable()
+			return m, nil
+		}
+	}
+
+	m.textinput, cmd = m.textinput.Update(msg)
+	return m, cmd
+}
+
+// viewTableEdit renders the table being edited with the current cell highlighted
+func (m uiModel) viewTableEdit() string {
+	var content strings.Builder
+	content.WriteString(m.styles.titleStyle.Render("Edit Table: " + m.editingItem.Title))
+	content.WriteString("\n\n")
+
+	for _, line := range m.renderTable(m.table, m.tableCell, m.textinput.Value()) {
+		content.WriteString(line)
+		content.WriteString("\n")
+	}
+
+	// Org addresses cells as @row$column, counting rows without rules
+	rowNumber := 0
+	for i := 0; i <= m.tableCell.row; i++ {
+		if !m.table.Rows[i].Rule {
+			rowNumber++
+		}
+	}
+	content.WriteString("\n")
+	content.WriteString(fmt.Sprintf("@%d$%d: ", rowNumber, m.tableCell.col+1))
+	content.WriteString(m.textinput.View())
+	content.WriteString("\n\n")
+
+	if m.statusMsg != "" && time.Now().Before(m.statusExpiry) {
+		content.WriteString(m.styles.statusStyle.Render(m.statusMsg))
+		content.WriteString("\n")
+	}
+	content.WriteString(m.styles.statusStyle.Render("Tab/Shift+Tab next/prev cell • ↑/↓ row • Enter next row • PgUp/PgDn other table"))
+	content.WriteString("\n")
+	content.WriteString(m.styles.statusStyle.Render("Ctrl+O/Ctrl+K add/delete row • Ctrl+L/Ctrl+X add/delete column • Ctrl+R rule • ESC save"))
+	return content.String()
+}
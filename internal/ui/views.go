@@ -67,6 +67,26 @@ func (m uiModel) renderFullHelp() string {
 }
 
 func (m uiModel) View() string {
+	// The running clock is shown below every view, which gets one line less
+	clockLine := m.renderClockLine()
+	if clockLine == "" {
+		return m.viewContent()
+	}
+	m.height = m.viewHeight()
+	return m.viewContent() + "\n" + clockLine
+}
+
+// viewHeight returns the height left to the view of the current mode, above the
+// running clock
+func (m uiModel) viewHeight() int {
+	if m.renderClockLine() != "" {
+		return m.height - 1
+	}
+	return m.height
+}
+
+// viewContent renders the current mode's view
+func (m uiModel) viewContent() string {
 	switch m.mode {
 	case modeEdit:
 		return m.viewEditMode()
@@ -96,6 +116,32 @@ func (m uiModel) View() string {
 		return m.viewTagEdit()
 	case modeRename:
 		return m.viewRename()
+	case modeFilter:
+		return m.viewFilter()
+	case modeEffortReport:
+		return m.viewEffortReport()
+	case modeColumns:
+		return m.viewColumns()
+	case modeLinkSelect:
+		return m.viewLinkSelect()
+	case modeInsertLink:
+		return m.viewInsertLink()
+	case modeTableEdit:
+		return m.viewTableEdit()
+	case modeConfirmExecute:
+		return m.viewConfirmExecute()
+	case modeNewFile:
+		return m.viewNewFile()
+	case modeMoveToFile:
+		return m.viewMoveToFile()
+	case modeResolveClock:
+		return m.viewResolveClock()
+	case modeClockIdle:
+		return m.viewClockIdle()
+	case modeClockHistory:
+		return m.viewClockHistory()
+	case modeSort:
+		return m.viewSort()
 	}
 
 	// Build footer (status + help)
@@ -107,6 +153,12 @@ func (m uiModel) View() string {
 		footer.WriteString("\n")
 	}
 
+	// Active filter / narrowing
+	if m.filter != nil || m.narrowRoot != nil {
+		footer.WriteString(m.styles.scheduledStyle.Render(m.filterStatus()))
+		footer.WriteString("\n")
+	}
+
 	// Help
 	if m.help.ShowAll {
 		footer.WriteString(m.renderFullHelp())
@@ -131,6 +183,12 @@ func (m uiModel) View() string {
 	} else {
 		content.WriteString(m.styles.titleStyle.Render(title))
 	}
+	if m.visualMode {
+		content.WriteString(m.styles.markedStyle.Render(" [VISUAL]"))
+	}
+	if len(m.marked) > 0 {
+		content.WriteString(m.styles.markedStyle.Render(fmt.Sprintf(" [%d MARKED]", len(m.marked))))
+	}
 	content.WriteString("\n\n")
 
 	// Calculate available height for items (total - title - footer)
@@ -142,7 +200,11 @@ func (m uiModel) View() string {
 	// Items
 	items := m.getVisibleItems()
 	if len(items) == 0 {
-		content.WriteString("No items. Press 'c' to capture a new TODO.\n")
+		if m.filter != nil {
+			content.WriteString("No items match the filter. Press 'F' to clear it.\n")
+		} else {
+			content.WriteString("No items. Press 'c' to capture a new TODO.\n")
+		}
 	}
 
 	// Build a map of item index to line count (for scrolling)
@@ -215,7 +277,7 @@ func (m uiModel) View() string {
 			if linesToSkip < itemLineCount[i] {
 				// Render the visible parts
 				if linesToSkip == 0 {
-					line := m.renderItem(item, i == m.cursor)
+					line := m.renderItem(item, i == m.cursor, m.isSelected(item, i))
 					content.WriteString(line)
 					content.WriteString("\n")
 					itemLines++
@@ -254,7 +316,7 @@ func (m uiModel) View() string {
 		}
 
 		// Render the full item
-		line := m.renderItem(item, i == m.cursor)
+		line := m.renderItem(item, i == m.cursor, m.isSelected(item, i))
 		content.WriteString(line)
 		content.WriteString("\n")
 		itemLines++
@@ -315,18 +377,41 @@ func (m uiModel) viewConfirmDelete() string {
 		Padding(1, 2).
 		Width(60)
 
+	isFile := len(m.bulkItems) == 0 && m.itemToDelete != nil && m.itemToDelete.Wrapper == model.FileWrapper
+
 	var content strings.Builder
-	content.WriteString(m.styles.titleStyle.Render("⚠ Delete Item"))
+	if isFile {
+		content.WriteString(m.styles.titleStyle.Render("⚠ Delete File"))
+	} else {
+		content.WriteString(m.styles.titleStyle.Render("⚠ Delete Item"))
+	}
 	content.WriteString("\n\n")
 
-	if m.itemToDelete != nil {
-		itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("202")).Bold(true)
+	itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("202")).Bold(true)
+	if len(m.bulkItems) > 0 {
+		content.WriteString(itemStyle.Render(fmt.Sprintf("%d selected items", len(m.bulkItems))))
+		content.WriteString("\n")
+		for i, item := range m.bulkItems {
+			if i >= 5 {
+				content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("  ... and %d more", len(m.bulkItems)-i)))
+				content.WriteString("\n")
+				break
+			}
+			content.WriteString("  " + item.Title + "\n")
+		}
+	} else if m.itemToDelete != nil {
 		content.WriteString(itemStyle.Render(m.itemToDelete.Title))
 		content.WriteString("\n")
 	}
 
 	content.WriteString("\n")
-	content.WriteString(m.styles.statusStyle.Render("This will delete the item and all sub-tasks."))
+	if isFile {
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("This will move %s to the trash.", m.itemToDelete.SourceFile)))
+	} else if len(m.bulkItems) > 0 {
+		content.WriteString(m.styles.statusStyle.Render("This will delete the items and all their sub-tasks."))
+	} else {
+		content.WriteString(m.styles.statusStyle.Render("This will delete the item and all sub-tasks."))
+	}
 	content.WriteString("\n\n")
 	content.WriteString("Press Y to confirm • N or ESC to cancel")
 
@@ -399,7 +484,7 @@ func (m uiModel) viewSetDate(title, helpMsg string) string {
 	content.WriteString(m.styles.titleStyle.Render(title))
 	content.WriteString("\n")
 	if m.editingItem != nil {
-		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.editingItem.Title)))
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.promptTargetLabel())))
 	}
 	content.WriteString("\n\n")
 	content.WriteString(m.textinput.View())
@@ -427,9 +512,9 @@ func (m uiModel) viewSetPriority() string {
 	content.WriteString(m.styles.titleStyle.Render("Set Priority"))
 	content.WriteString("\n")
 	if m.editingItem != nil {
-		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.editingItem.Title)))
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.promptTargetLabel())))
 		content.WriteString("\n")
-		if m.editingItem.Priority != model.PriorityNone {
+		if len(m.bulkItems) == 0 && m.editingItem.Priority != model.PriorityNone {
 			content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Current: [#%s]", m.editingItem.Priority)))
 		}
 	}
@@ -467,14 +552,14 @@ func (m uiModel) viewSetEffort() string {
 	if m.editingItem != nil {
 		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.editingItem.Title)))
 		content.WriteString("\n")
-		if m.editingItem.Effort != "" {
-			content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Current: %s", m.editingItem.Effort)))
+		if effort := m.editingItem.GetEffort(); effort != "" {
+			content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Current: %s", effort)))
 		}
 	}
 	content.WriteString("\n\n")
 	content.WriteString(m.textinput.View())
 	content.WriteString("\n\n")
-	content.WriteString(m.styles.statusStyle.Render("Examples: 8h, 2d, 1w, 4h30m"))
+	content.WriteString(m.styles.statusStyle.Render("Examples: 1:30, 8h, 2d, 1w, 4h30m, 1d 2:00"))
 	content.WriteString("\n")
 	content.WriteString(m.styles.statusStyle.Render("Leave empty to clear effort"))
 	content.WriteString("\n")
@@ -496,11 +581,14 @@ func (m uiModel) viewHelp() string {
 
 	// Group bindings by category
 	navigationBindings := []key.Binding{m.keys.Up, m.keys.Down, m.keys.Left, m.keys.Right}
-	itemBindings := []key.Binding{m.keys.ToggleFold, m.keys.EditNotes, m.keys.CycleState}
-	taskBindings := []key.Binding{m.keys.Capture, m.keys.AddSubTask, m.keys.Delete}
-	timeBindings := []key.Binding{m.keys.ClockIn, m.keys.ClockOut, m.keys.SetDeadline, m.keys.SetScheduled, m.keys.SetEffort}
-	organizationBindings := []key.Binding{m.keys.SetPriority, m.keys.TagItem, m.keys.ShiftUp, m.keys.ShiftDown, m.keys.ToggleReorder}
-	viewBindings := []key.Binding{m.keys.ToggleView, m.keys.Settings, m.keys.Save, m.keys.Help, m.keys.Quit}
+	itemBindings := []key.Binding{m.keys.ToggleFold, m.keys.EditNotes, m.keys.EditExternal, m.keys.EditSubtree, m.keys.EditTable, m.keys.ExecuteBlock, m.keys.CycleState}
+	taskBindings := []key.Binding{m.keys.Capture, m.keys.AddSubTask, m.keys.Delete, m.keys.NewFile, m.keys.MoveToFile}
+	timeBindings := []key.Binding{m.keys.ClockIn, m.keys.ClockOut, m.keys.JumpToClock, m.keys.ClockHistory, m.keys.Pomodoro, m.keys.SetDeadline, m.keys.SetScheduled, m.keys.SetEffort, m.keys.EffortReport}
+	organizationBindings := []key.Binding{m.keys.SetPriority, m.keys.TagItem, m.keys.ShiftUp, m.keys.ShiftDown, m.keys.ToggleReorder, m.keys.SortItems}
+	viewBindings := []key.Binding{m.keys.ToggleView, m.keys.ColumnView, m.keys.Settings, m.keys.Save, m.keys.Help, m.keys.Quit}
+	selectionBindings := []key.Binding{m.keys.ToggleMark, m.keys.ToggleVisual, m.keys.ClearMarks}
+	filterBindings := []key.Binding{m.keys.Filter, m.keys.ClearFilter, m.keys.Narrow, m.keys.Widen}
+	linkBindings := []key.Binding{m.keys.FollowLink, m.keys.InsertLink, m.keys.AssignID}
 
 	// Helper function to render a binding
 	renderBinding := func(b key.Binding) string {
@@ -543,6 +631,24 @@ func (m uiModel) viewHelp() string {
 	}
 	lines = append(lines, "")
 
+	lines = append(lines, categoryStyle.Render("Selection"))
+	for _, binding := range selectionBindings {
+		lines = append(lines, renderBinding(binding))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, categoryStyle.Render("Filtering"))
+	for _, binding := range filterBindings {
+		lines = append(lines, renderBinding(binding))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, categoryStyle.Render("Links"))
+	for _, binding := range linkBindings {
+		lines = append(lines, renderBinding(binding))
+	}
+	lines = append(lines, "")
+
 	lines = append(lines, categoryStyle.Render("View & System"))
 	for _, binding := range viewBindings {
 		lines = append(lines, renderBinding(binding))
@@ -624,10 +730,24 @@ func filterLogbookDrawer(notes []string) []string {
 	var filtered []string
 	inLogbook := false
 	inProperties := false
+	blockName := "" // Lines in blocks are kept even if they look like drawers
 
-	for _, note := range notes {
+	for i, note := range notes {
 		trimmed := strings.TrimSpace(note)
 
+		if blockName != "" {
+			if name, ok := model.BlockEnd(note); ok && name == blockName {
+				blockName = ""
+			}
+			filtered = append(filtered, note)
+			continue
+		}
+		if name, ok := model.BlockBegin(note); ok && !inLogbook && !inProperties && model.HasBlockEnd(notes[i+1:], name) {
+			blockName = name
+			filtered = append(filtered, note)
+			continue
+		}
+
 		// Check for start of LOGBOOK drawer
 		if trimmed == ":LOGBOOK:" {
 			inLogbook = true
@@ -662,6 +782,11 @@ func filterLogbookDrawer(notes []string) []string {
 			continue
 		}
 
+		// Skip blank lines between the heading and the first shown line
+		if trimmed == "" && len(filtered) == 0 {
+			continue
+		}
+
 		filtered = append(filtered, note)
 	}
 
@@ -672,26 +797,28 @@ func filterLogbookDrawer(notes []string) []string {
 func wrapNoteLines(notes []string, width int, indent string) []string {
 	var wrapped []string
 	for _, note := range notes {
-		// Don't wrap code block delimiters or drawer markers
+		// Don't wrap block delimiters, drawer markers or tables
 		trimmed := strings.TrimSpace(note)
-		if strings.HasPrefix(trimmed, "#+BEGIN_SRC") ||
-			strings.HasPrefix(trimmed, "#+END_SRC") ||
+		if model.IsBlockDelimiter(note) ||
 			strings.HasPrefix(trimmed, "```") ||
 			trimmed == ":LOGBOOK:" ||
 			trimmed == ":PROPERTIES:" ||
-			trimmed == ":END:" {
+			trimmed == ":END:" ||
+			model.IsTableLine(note) ||
+			model.IsTableFormulaLine(note) {
 			wrapped = append(wrapped, note)
 			continue
 		}
 
-		// Wrap the note line
-		wrappedLines := wrapText(note, width, indent)
+		// Wrap the note line, keeping links and short emphasized phrases whole
+		wrappedLines := wrapText(protectEmphasis(protectLinks(note), width-lipgloss.Width(indent)), width, indent)
 		wrapped = append(wrapped, wrappedLines...)
 	}
 	return wrapped
 }
 
 // renderNotesWithHighlighting renders notes with syntax highlighting for code blocks
+// and styling for other blocks
 func (m uiModel) renderNotesWithHighlighting(notes []string) []string {
 	if len(notes) == 0 {
 		return notes
@@ -702,12 +829,41 @@ func (m uiModel) renderNotesWithHighlighting(notes []string) []string {
 	var codeLanguage string
 	var codeLines []string
 	var codeBlockDelimiter string // Track whether we're in #+BEGIN_SRC or ``` block
+	var blockName string          // Name of an open QUOTE, EXAMPLE or other non-code block
 
-	for _, note := range notes {
+	for i := 0; i < len(notes); i++ {
+		note := notes[i]
 		trimmed := strings.TrimSpace(note)
 
+		// Lines in other blocks are styled by the block type, with dimmed delimiters
+		if blockName != "" {
+			if name, ok := model.BlockEnd(note); ok && name == blockName {
+				blockName = ""
+				result = append(result, m.styles.foldedStyle.Render(note))
+			} else {
+				result = append(result, m.renderBlockLine(blockName, note))
+			}
+			continue
+		}
+		if name, ok := model.BlockBegin(note); ok && !inCodeBlock && name != "SRC" {
+			// Dynamic blocks hold generated org text, which is rendered as usual
+			if name == model.DynamicBlock {
+				result = append(result, m.styles.scheduledStyle.Render(note))
+				continue
+			}
+			if model.HasBlockEnd(notes[i+1:], name) {
+				blockName = name
+				result = append(result, m.styles.foldedStyle.Render(note))
+				continue
+			}
+		}
+		if name, ok := model.BlockEnd(note); ok && !inCodeBlock && name == model.DynamicBlock {
+			result = append(result, m.styles.scheduledStyle.Render(note))
+			continue
+		}
+
 		// Check for org-mode style code block start
-		if strings.HasPrefix(trimmed, "#+BEGIN_SRC") {
+		if name, ok := model.BlockBegin(note); ok && name == "SRC" && !inCodeBlock {
 			inCodeBlock = true
 			codeBlockDelimiter = "org"
 			// Extract language
@@ -765,7 +921,7 @@ func (m uiModel) renderNotesWithHighlighting(notes []string) []string {
 		}
 
 		// Check for org-mode style code block end
-		if strings.HasPrefix(trimmed, "#+END_SRC") {
+		if name, ok := model.BlockEnd(note); ok && name == "SRC" && codeBlockDelimiter == "org" {
 			inCodeBlock = false
 			// Highlight and add the code (or render LaTeX)
 			if len(codeLines) > 0 {
@@ -791,14 +947,33 @@ func (m uiModel) renderNotesWithHighlighting(notes []string) []string {
 
 		// If in code block, accumulate lines
 		if inCodeBlock {
-			codeLines = append(codeLines, note)
+			codeLines = append(codeLines, restoreLinks(note))
 		} else {
+			// Tables are drawn with borders, along with a following #+TBLFM line
+			if model.IsTableLine(note) {
+				end := i
+				for end < len(notes) && model.IsTableLine(notes[end]) {
+					end++
+				}
+				if end < len(notes) && model.IsTableFormulaLine(notes[end]) {
+					end++
+				}
+				table := model.FindTables(notes[i:end])[0]
+				result = append(result, m.renderTable(table, noTableCell, "")...)
+				i = end - 1
+				continue
+			}
+			// Lines with links or emphasis are rendered inline, hiding the org markup
+			if hasProtectedLinks(note) || (m.config.UI.OrgSyntaxHighlighting && hasEmphasis(note)) {
+				result = append(result, m.renderInline(note))
+				continue
+			}
 			// Apply org-mode syntax highlighting to non-code text if enabled
 			if m.config.UI.OrgSyntaxHighlighting {
 				highlighted := highlightCode(note, "org")
 				result = append(result, highlighted)
 			} else {
-				result = append(result, note)
+				result = append(result, restoreLinks(note))
 			}
 		}
 	}
@@ -822,6 +997,21 @@ func (m uiModel) renderNotesWithHighlighting(notes []string) []string {
 	return result
 }
 
+// renderBlockLine styles a line inside a block other than a code block
+func (m uiModel) renderBlockLine(blockName, line string) string {
+	switch blockName {
+	case "QUOTE", "VERSE":
+		return m.renderInlineStyled(line, m.styles.noteStyle)
+	case "EXAMPLE":
+		return m.styles.codeStyle.Render(restoreLinks(line))
+	case "EXPORT", "COMMENT":
+		return m.styles.foldedStyle.Render(restoreLinks(line))
+	default:
+		// Special blocks such as CENTER hold ordinary text
+		return m.renderInline(line)
+	}
+}
+
 // highlightCode applies syntax highlighting to code
 func highlightCode(code, language string) string {
 	if code == "" {
@@ -927,7 +1117,7 @@ func wrapText(text string, width int, indent string) []string {
 	return result
 }
 
-func (m uiModel) renderItem(item *model.Item, isCursor bool) string {
+func (m uiModel) renderItem(item *model.Item, isCursor bool, isSelected bool) string {
 	var b strings.Builder
 
 	// Indentation with subtle visual nesting guides
@@ -949,8 +1139,14 @@ func (m uiModel) renderItem(item *model.Item, isCursor bool) string {
 		}
 	}
 
+	// Selection marker
+	if isSelected {
+		b.WriteString(m.styles.markedStyle.Render("● "))
+	}
+
 	// Fold indicator
-	if len(item.Children) > 0 || len(item.Notes) > 0 {
+	// Blank lines kept below a heading don't make it foldable
+	if len(item.Children) > 0 || strings.TrimSpace(strings.Join(item.Notes, "")) != "" {
 		if item.Folded {
 			b.WriteString(m.styles.foldedStyle.Render("▶ "))
 		} else {
@@ -984,8 +1180,12 @@ func (m uiModel) renderItem(item *model.Item, isCursor bool) string {
 		b.WriteString(priorityStyle.Render(fmt.Sprintf("[#%s] ", item.Priority)))
 	}
 
-	// Title
-	b.WriteString(item.Title)
+	// Title (items only shown as context for a filter match are dimmed)
+	if m.filter != nil && !m.filter.matches(item) {
+		b.WriteString(m.renderInlineStyled(item.Title, m.styles.foldedStyle))
+	} else {
+		b.WriteString(m.renderInline(item.Title))
+	}
 
 	// Tags
 	if len(item.Tags) > 0 {
@@ -997,13 +1197,20 @@ func (m uiModel) renderItem(item *model.Item, isCursor bool) string {
 		}
 	}
 
-	// Effort
-	if item.Effort != "" {
-		effortStr := fmt.Sprintf(" (Effort: %s)", item.Effort)
-		effortStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")) // Purple
-		b.WriteString(effortStyle.Render(effortStr))
+	// Blocked indicator for open tasks with unfinished dependencies
+	if m.isOpenTask(item) {
+		if blockers := m.openBlockers(item); len(blockers) > 0 {
+			blockedStr := fmt.Sprintf(" [BLOCKED by %s]", blockers[0].Title)
+			if len(blockers) > 1 {
+				blockedStr = fmt.Sprintf(" [BLOCKED by %s +%d]", blockers[0].Title, len(blockers)-1)
+			}
+			b.WriteString(m.styles.blockStyle.Render(blockedStr))
+		}
 	}
 
+	// Effort estimate, subtree rollup and budget warning
+	b.WriteString(m.renderEffort(item))
+
 	// Clock status
 	if item.IsClockedIn() {
 		duration := item.GetCurrentClockDuration()
@@ -1016,19 +1223,7 @@ func (m uiModel) renderItem(item *model.Item, isCursor bool) string {
 
 	// Total clocked time (show if there are any clock entries)
 	if len(item.ClockEntries) > 0 {
-		totalDuration := item.GetTotalClockDuration()
-		totalHours := int(totalDuration.Hours())
-		totalMinutes := int(totalDuration.Minutes()) % 60
-
-		// Format the time display based on magnitude
-		var timeStr string
-		if totalHours > 0 {
-			timeStr = fmt.Sprintf("%dh %dm", totalHours, totalMinutes)
-		} else {
-			timeStr = fmt.Sprintf("%dm", totalMinutes)
-		}
-
-		totalTimeStr := fmt.Sprintf(" (Time: %s)", timeStr)
+		totalTimeStr := fmt.Sprintf(" (Time: %s)", formatDuration(item.GetTotalClockDuration()))
 		totalTimeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")) // Purple, similar to scheduled
 		b.WriteString(totalTimeStyle.Render(totalTimeStr))
 	}
@@ -1066,12 +1261,13 @@ func (m uiModel) viewTagEdit() string {
 	content.WriteString(m.styles.titleStyle.Render("Edit Tags") + "\n\n")
 
 	if m.editingItem != nil {
-		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.editingItem.Title)) + "\n\n")
+		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("For: %s", m.promptTargetLabel())) + "\n\n")
 	}
 
 	content.WriteString(m.textinput.View() + "\n\n")
 
 	content.WriteString(m.styles.statusStyle.Render("Enter tags separated by colons (e.g., work:urgent:important)") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("Prefix with + or - to add or remove tags (e.g., +review:-waiting)") + "\n")
 	content.WriteString(m.styles.statusStyle.Render("Leave empty to remove all tags") + "\n\n")
 	content.WriteString(m.styles.statusStyle.Render("Press Enter to save • ESC to cancel") + "\n")
 
@@ -1082,7 +1278,12 @@ func (m uiModel) viewTagEdit() string {
 func (m uiModel) viewRename() string {
 	var content strings.Builder
 
-	content.WriteString(m.styles.titleStyle.Render("Rename Item") + "\n\n")
+	isFile := m.editingItem != nil && m.editingItem.Wrapper == model.FileWrapper
+	if isFile {
+		content.WriteString(m.styles.titleStyle.Render("Rename File") + "\n\n")
+	} else {
+		content.WriteString(m.styles.titleStyle.Render("Rename Item") + "\n\n")
+	}
 
 	if m.editingItem != nil {
 		content.WriteString(m.styles.statusStyle.Render(fmt.Sprintf("Current: %s", m.editingItem.Title)) + "\n\n")
@@ -1090,8 +1291,32 @@ func (m uiModel) viewRename() string {
 
 	content.WriteString(m.textinput.View() + "\n\n")
 
-	content.WriteString(m.styles.statusStyle.Render("Enter new title for the item") + "\n\n")
+	if isFile {
+		content.WriteString(m.styles.statusStyle.Render("Enter the new file name; the file is renamed on disk") + "\n\n")
+	} else {
+		content.WriteString(m.styles.statusStyle.Render("Enter new title for the item") + "\n\n")
+	}
 	content.WriteString(m.styles.statusStyle.Render("Press Enter to save • ESC to cancel") + "\n")
 
 	return content.String()
 }
+
+// viewFilter renders the filter prompt
+func (m uiModel) viewFilter() string {
+	var content strings.Builder
+
+	content.WriteString(m.styles.titleStyle.Render("Filter Items") + "\n\n")
+	content.WriteString(m.textinput.View() + "\n\n")
+
+	content.WriteString(m.styles.statusStyle.Render("tag:work,home    items with any of the tags") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("-tag:someday     items without the tag") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("state:TODO,PROG  items in any of the states (-state:DONE hides DONE)") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("prio:A,B         items with priority A or B") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("has:deadline     items with a deadline (or has:scheduled)") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("file:work.org    items from one file (multi-file mode)") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("word             items whose title contains the word") + "\n\n")
+	content.WriteString(m.styles.statusStyle.Render("Leave empty to clear the filter") + "\n")
+	content.WriteString(m.styles.statusStyle.Render("Press Enter to apply • ESC to cancel") + "\n")
+
+	return content.String()
+}